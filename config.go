@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/ardanlabs/conf/v2"
 	"gopkg.in/yaml.v3"
@@ -16,12 +17,33 @@ type BotConfig struct {
 	RedisURL  string `conf:"default:redis://localhost:6379,flag:redis-url,short:r,help:redis URL"`
 	LogLevel  string `conf:"default:info,flag:log-level,short:l,help:Minimium log level"`
 	CASUpdate bool   `conf:"default:true,flag:cas-update,help:Update automatically CAS database"`
+	CASFile   string `conf:"flag:cas-file,help:CAS database file (CSV export) loaded at startup"`
+	CASCheck  string `conf:"default:https://api.cas.chat/check,flag:cas-check-url,help:CAS endpoint to check single users (empty to disable)"`
 	Git       struct {
 		TmpDir     string `conf:"default:-,flag:git-dir,help:git temporary director"`
 		SSHKey     string `conf:"default:-,flag:git-ssh-key,help:SSH key used with git"`
 		SSHKeyPass string `conf:"default:-,flag:git-ssh-key-pass,help:SSH key's password"`
 	}
+	Duplicate struct {
+		Chats  uint          `conf:"default:5,flag:duplicate-chats,help:Number of chats above which the same message from a user is spam (0 disables)"`
+		Window time.Duration `conf:"default:10m,flag:duplicate-window,help:Time window for duplicate messages across chats"`
+		GLine  bool          `conf:"default:false,flag:duplicate-gline,help:G-Line users sending duplicate messages across chats"`
+	}
 	GlobalAdmin int64 `conf:"default:0,flag:global-admin,short:g,help:Default global admin"`
+
+	// BanLists are other ban lists to subscribe, besides CAS. They can be set
+	// only in the config file
+	BanLists []BanListConfig `conf:"-"`
+}
+
+// BanListConfig describes a ban list (see banlist.Options).
+type BanListConfig struct {
+	Name     string        `yaml:"name"`
+	URL      string        `yaml:"url"`
+	Format   string        `yaml:"format"`
+	Column   string        `yaml:"column"`
+	Interval time.Duration `yaml:"interval"`
+	NonEmpty bool          `yaml:"non_empty"`
 }
 
 // getConfig returns a BotConfig struct with loaded values from environment
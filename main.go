@@ -8,7 +8,9 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // Time zones for quiet hours, missing in the container image
 
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/banlist"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/bot"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/cas"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
@@ -86,10 +88,54 @@ func run() error {
 
 	// Initialize CAS database.
 	log.Info("Initializing CAS database")
-	casDB, err := cas.New(cfg.CASUpdate, log, nil)
+	casDB, err := cas.New(cas.Options{
+		AutoUpdate: cfg.CASUpdate,
+		Logger:     log,
+		Store:      botdb,
+		File:       cfg.CASFile,
+		CheckURL:   cfg.CASCheck,
+		Cache:      botdb,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create CAS database: %w", err)
 	}
+	defer func() {
+		if err := casDB.Close(); err != nil {
+			log.WithError(err).Error("failed to close CAS database")
+		}
+	}()
+
+	// Initialize other ban lists. They are updated automatically if CAS is.
+	var banLists []banlist.BanListProvider
+	names := map[string]bool{cas.Name: true}
+	for _, bl := range cfg.BanLists {
+		if names[bl.Name] {
+			return fmt.Errorf("duplicate ban list name %q", bl.Name)
+		}
+		names[bl.Name] = true
+
+		log.Info("Initializing ban list ", bl.Name)
+		list, err := banlist.New(banlist.Options{
+			Name:       bl.Name,
+			URL:        bl.URL,
+			Format:     banlist.Format(bl.Format),
+			Column:     bl.Column,
+			Interval:   bl.Interval,
+			NonEmpty:   bl.NonEmpty,
+			AutoUpdate: cfg.CASUpdate,
+			Logger:     log,
+			Store:      botdb,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create ban list %q: %w", bl.Name, err)
+		}
+		defer func() {
+			if err := list.Close(); err != nil {
+				log.WithError(err).Error("failed to close ban list")
+			}
+		}()
+		banLists = append(banLists, list)
+	}
 
 	// Initialize i18n.
 	log.Info("Initializing i18n support")
@@ -105,10 +151,14 @@ func run() error {
 		Database:            botdb,
 		Token:               cfg.BotToken,
 		CAS:                 casDB,
+		BanLists:            banLists,
 		Bundle:              bundle,
 		GitTemporaryDir:     cfg.Git.TmpDir,
 		GitSSHKeyFile:       cfg.Git.SSHKey,
 		GitSSHKeyPassphrase: cfg.Git.SSHKeyPass,
+		DuplicateChats:      cfg.Duplicate.Chats,
+		DuplicateWindow:     cfg.Duplicate.Window,
+		DuplicateGLine:      cfg.Duplicate.GLine,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create bot: %w", err)
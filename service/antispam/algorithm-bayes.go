@@ -0,0 +1,220 @@
+package antispam
+
+import (
+	"crypto/sha1" // #nosec G505 not used for cryptographic purposes
+	"encoding/hex"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// maxBayesTokens is the maximum number of tokens of a text used for training
+// and scoring.
+const maxBayesTokens = 200
+
+// BayesStore is the storage of the Bayes classifier model. The model is shared:
+// every text trained by any chat changes the score for all chats.
+type BayesStore interface {
+	// BayesCounts returns the number of trained spam and ham documents, and the
+	// number of spam and ham documents containing each one of the given tokens.
+	BayesCounts(tokens []string) (spamDocs int64, hamDocs int64, spam []int64, ham []int64, err error)
+
+	// BayesLearn adds the document with the given hash and tokens to the
+	// given class (spam or ham): the document count of the class, and the
+	// count of each token for that class, are incremented. If the document was
+	// already added to the other class, it is removed from there first; if it
+	// was already added to the same class, nothing changes.
+	//
+	// It must be atomic, so that concurrent calls for the same document count
+	// it once.
+	BayesLearn(hash string, spam bool, tokens []string) error
+}
+
+// BayesTokens splits the given text into the set of tokens used by the Bayes
+// classifier: lower-case words of at least two chars, without duplicates.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func BayesTokens(text string) []string {
+	seen := make(map[string]struct{})
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) < 2 {
+			continue
+		}
+		if _, found := seen[word]; found {
+			continue
+		}
+		seen[word] = struct{}{}
+		tokens = append(tokens, word)
+		if len(tokens) == maxBayesTokens {
+			break
+		}
+	}
+	return tokens
+}
+
+// BayesClassifier is a naive Bayes text classifier. It learns from texts
+// classified as spam or ham (not spam) and returns the probability that a text
+// is spam.
+type BayesClassifier struct {
+	store BayesStore
+}
+
+// NewBayesClassifier returns a BayesClassifier that keeps its model in the
+// given store.
+func NewBayesClassifier(store BayesStore) *BayesClassifier {
+	return &BayesClassifier{store: store}
+}
+
+// Learn trains the classifier with the given text as spam (or ham). If the
+// same text was already trained with the other class, the previous training is
+// reverted first, so Learn can be used to correct a wrong classification.
+func (c *BayesClassifier) Learn(text string, spam bool) error {
+	tokens := BayesTokens(text)
+	if len(tokens) == 0 {
+		return nil
+	}
+	return c.store.BayesLearn(bayesHash(tokens), spam, tokens)
+}
+
+// Score returns the probability that the given text is spam, between 0 and 1.
+// If the classifier was never trained with both classes, it returns 0.
+//
+// Time complexity: O(n) where "n" is the number of tokens in the text.
+func (c *BayesClassifier) Score(text string) (float64, error) {
+	tokens := BayesTokens(text)
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	spamDocs, hamDocs, spam, ham, err := c.store.BayesCounts(tokens)
+	if err != nil {
+		return 0, err
+	}
+	if spamDocs <= 0 || hamDocs <= 0 {
+		return 0, nil
+	}
+
+	// Bernoulli naive Bayes on the tokens in the text, with Laplace smoothing.
+	// Logarithms avoid underflows on long texts.
+	logSpam := math.Log(float64(spamDocs) / float64(spamDocs+hamDocs))
+	logHam := math.Log(float64(hamDocs) / float64(spamDocs+hamDocs))
+	for i := range tokens {
+		logSpam += math.Log(float64(spam[i]+1) / float64(spamDocs+2))
+		logHam += math.Log(float64(ham[i]+1) / float64(hamDocs+2))
+	}
+	return 1 / (1 + math.Exp(logHam-logSpam)), nil
+}
+
+// bayesHash returns the hash that identifies a trained document from its
+// tokens.
+func bayesHash(tokens []string) string {
+	s := sha1.New() // #nosec G401 not used for cryptographic purposes
+	_, _ = s.Write([]byte(strings.Join(tokens, " ")))
+	return hex.EncodeToString(s.Sum(nil))
+}
+
+// BayesDetector is a Detector that uses a BayesClassifier.
+type BayesDetector struct {
+	classifier *BayesClassifier
+	onError    func(error)
+}
+
+// NewBayesDetector returns a BayesDetector for the given classifier. If onError
+// is not nil, it is called when the classifier fails to score a text (in that
+// case, the score is zero).
+func NewBayesDetector(classifier *BayesClassifier, onError func(error)) BayesDetector {
+	return BayesDetector{
+		classifier: classifier,
+		onError:    onError,
+	}
+}
+
+// Name returns "spam".
+func (d BayesDetector) Name() string { return "spam" }
+
+// Threshold returns the default threshold, a 90% spam probability.
+func (d BayesDetector) Threshold() float64 { return 0.9 }
+
+// Detect returns the probability that the text is spam.
+func (d BayesDetector) Detect(text string) (float64, string) {
+	score, err := d.classifier.Score(text)
+	if err != nil {
+		if d.onError != nil {
+			d.onError(err)
+		}
+		return 0, ""
+	}
+	return score, "Spam classifier"
+}
+
+// MemoryBayesStore is a BayesStore that keeps the model in memory. It is safe
+// for concurrent use, and it is useful for offline training and tests.
+type MemoryBayesStore struct {
+	mu       sync.Mutex
+	docs     [2]int64
+	tokens   [2]map[string]int64
+	trained  map[string]bool
+	initOnce sync.Once
+}
+
+// classIndex returns the index of the class in MemoryBayesStore arrays.
+func classIndex(spam bool) int {
+	if spam {
+		return 1
+	}
+	return 0
+}
+
+func (s *MemoryBayesStore) init() {
+	s.initOnce.Do(func() {
+		s.tokens = [2]map[string]int64{{}, {}}
+		s.trained = make(map[string]bool)
+	})
+}
+
+// BayesCounts implements BayesStore.
+func (s *MemoryBayesStore) BayesCounts(tokens []string) (int64, int64, []int64, []int64, error) {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spam := make([]int64, len(tokens))
+	ham := make([]int64, len(tokens))
+	for i, token := range tokens {
+		spam[i] = s.tokens[classIndex(true)][token]
+		ham[i] = s.tokens[classIndex(false)][token]
+	}
+	return s.docs[classIndex(true)], s.docs[classIndex(false)], spam, ham, nil
+}
+
+// BayesLearn implements BayesStore.
+func (s *MemoryBayesStore) BayesLearn(hash string, spam bool, tokens []string) error {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wasSpam, found := s.trained[hash]
+	if found && wasSpam == spam {
+		return nil
+	}
+	if found {
+		s.train(wasSpam, tokens, -1)
+	}
+	s.train(spam, tokens, 1)
+	s.trained[hash] = spam
+	return nil
+}
+
+// train adds delta to the document count of the given class, and to the count
+// of each token for that class. The lock must be held.
+func (s *MemoryBayesStore) train(spam bool, tokens []string, delta int64) {
+	class := classIndex(spam)
+	s.docs[class] += delta
+	for _, token := range tokens {
+		s.tokens[class][token] += delta
+	}
+}
@@ -0,0 +1,198 @@
+package antispam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBayesTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"lower case", "Hello WORLD", []string{"hello", "world"}},
+		{"punctuation", "free-money!!! click, now.", []string{"free", "money", "click", "now"}},
+		{"short words", "a b cd e", []string{"cd"}},
+		{"duplicates", "buy buy BUY now", []string{"buy", "now"}},
+		{"numbers", "win 1000 € now", []string{"win", "1000", "now"}},
+		{"unicode", "Ciao è così", []string{"ciao", "così"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BayesTokens(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BayesTokens(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBayesTokensLimit(t *testing.T) {
+	text := ""
+	for i := 0; i < 2*maxBayesTokens; i++ {
+		text += string(rune('a'+i%26)) + string(rune('a'+i/26)) + " "
+	}
+	if got := len(BayesTokens(text)); got != maxBayesTokens {
+		t.Errorf("len(BayesTokens) = %d, want %d", got, maxBayesTokens)
+	}
+}
+
+// bayesExample is a text learned by the classifier.
+type bayesExample struct {
+	text string
+	spam bool
+}
+
+// bayesCounts returns the document counts and the counts of the given tokens
+// in the given store.
+func bayesCounts(t *testing.T, store *MemoryBayesStore, tokens ...string) (int64, int64, []int64, []int64) {
+	t.Helper()
+	spamDocs, hamDocs, spam, ham, err := store.BayesCounts(tokens)
+	if err != nil {
+		t.Fatalf("BayesCounts: %v", err)
+	}
+	return spamDocs, hamDocs, spam, ham
+}
+
+func TestBayesClassifierLearn(t *testing.T) {
+	tests := []struct {
+		name     string
+		learn    []bayesExample
+		spamDocs int64
+		hamDocs  int64
+		spam     []int64 // Counts of "free" and "money".
+		ham      []int64
+	}{
+		{
+			name:     "spam",
+			learn:    []bayesExample{{"free money", true}},
+			spamDocs: 1,
+			spam:     []int64{1, 1},
+			ham:      []int64{0, 0},
+		},
+		{
+			name:    "ham",
+			learn:   []bayesExample{{"free lunch", false}},
+			hamDocs: 1,
+			spam:    []int64{0, 0},
+			ham:     []int64{1, 0},
+		},
+		{
+			name:     "same text twice",
+			learn:    []bayesExample{{"free money", true}, {"FREE money!", true}},
+			spamDocs: 1,
+			spam:     []int64{1, 1},
+			ham:      []int64{0, 0},
+		},
+		{
+			name:     "different texts",
+			learn:    []bayesExample{{"free money", true}, {"money now", true}, {"free lunch", false}},
+			spamDocs: 2,
+			hamDocs:  1,
+			spam:     []int64{1, 2},
+			ham:      []int64{1, 0},
+		},
+		{
+			name:    "relabelled as ham",
+			learn:   []bayesExample{{"free money", true}, {"free money", false}},
+			hamDocs: 1,
+			spam:    []int64{0, 0},
+			ham:     []int64{1, 1},
+		},
+		{
+			name:     "relabelled twice",
+			learn:    []bayesExample{{"free money", true}, {"free money", false}, {"free money", true}},
+			spamDocs: 1,
+			spam:     []int64{1, 1},
+			ham:      []int64{0, 0},
+		},
+		{
+			name:  "no tokens",
+			learn: []bayesExample{{"! ? a", true}},
+			spam:  []int64{0, 0},
+			ham:   []int64{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MemoryBayesStore{}
+			c := NewBayesClassifier(store)
+			for _, ex := range tt.learn {
+				if err := c.Learn(ex.text, ex.spam); err != nil {
+					t.Fatalf("Learn(%q, %v): %v", ex.text, ex.spam, err)
+				}
+			}
+
+			spamDocs, hamDocs, spam, ham := bayesCounts(t, store, "free", "money")
+			if spamDocs != tt.spamDocs || hamDocs != tt.hamDocs {
+				t.Errorf("documents = %d spam, %d ham, want %d spam, %d ham", spamDocs, hamDocs, tt.spamDocs, tt.hamDocs)
+			}
+			if !reflect.DeepEqual(spam, tt.spam) || !reflect.DeepEqual(ham, tt.ham) {
+				t.Errorf("tokens = %v spam, %v ham, want %v spam, %v ham", spam, ham, tt.spam, tt.ham)
+			}
+		})
+	}
+}
+
+// bayesTraining is a small training set of spam and ham messages.
+var bayesTraining = []bayesExample{
+	{"Earn free money now, click the link", true},
+	{"Free crypto giveaway, send money to get double", true},
+	{"Click here to earn money from home", true},
+	{"Investment opportunity, guaranteed profit, contact me", true},
+	{"Does anyone have the notes of the algebra lesson?", false},
+	{"The exam of tomorrow is moved to room 3", false},
+	{"Thanks, see you at the lesson", false},
+	{"Who is coming to the study group tonight?", false},
+}
+
+func TestBayesClassifierScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		training []bayesExample
+		text     string
+		min, max float64
+	}{
+		{"untrained", nil, "free money", 0, 0},
+		{"only spam trained", bayesTraining[:4], "free money", 0, 0},
+		{"no tokens", bayesTraining, "!!!", 0, 0},
+		{"spam", bayesTraining, "earn free money, click now", 0.9, 1},
+		{"ham", bayesTraining, "notes of the algebra exam", 0, 0.1},
+		{"unknown words", bayesTraining, "lorem ipsum", 0.4, 0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewBayesClassifier(&MemoryBayesStore{})
+			for _, ex := range tt.training {
+				if err := c.Learn(ex.text, ex.spam); err != nil {
+					t.Fatalf("Learn(%q, %v): %v", ex.text, ex.spam, err)
+				}
+			}
+			score, err := c.Score(tt.text)
+			if err != nil {
+				t.Fatalf("Score(%q): %v", tt.text, err)
+			}
+			if score < tt.min || score > tt.max {
+				t.Errorf("Score(%q) = %f, want between %f and %f", tt.text, score, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBayesDetector(t *testing.T) {
+	c := NewBayesClassifier(&MemoryBayesStore{})
+	for _, ex := range bayesTraining {
+		if err := c.Learn(ex.text, ex.spam); err != nil {
+			t.Fatalf("Learn(%q, %v): %v", ex.text, ex.spam, err)
+		}
+	}
+
+	d := NewBayesDetector(c, nil)
+	if score, reason := d.Detect("earn free money, click now"); score <= d.Threshold() || reason == "" {
+		t.Errorf("Detect = %f, %q, want a score above %f", score, reason, d.Threshold())
+	}
+	if score, _ := d.Detect("notes of the algebra exam"); score >= d.Threshold() {
+		t.Errorf("Detect = %f, want a score below %f", score, d.Threshold())
+	}
+}
@@ -0,0 +1,269 @@
+package antispam
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// MentionScale is the number of mentions for which MentionDetector returns
+// the maximum score: the score is the number of mentions divided by
+// MentionScale.
+const MentionScale = 20
+
+// MentionDetector is a Detector for messages with too many mentions. It
+// implements HiddenMentionsDetector, to count Telegram text mentions too.
+type MentionDetector struct{}
+
+// NewMentionDetector returns a MentionDetector.
+func NewMentionDetector() MentionDetector {
+	return MentionDetector{}
+}
+
+// Name returns "mentions".
+func (d MentionDetector) Name() string { return "mentions" }
+
+// Threshold returns the default threshold: more than 5 mentions.
+func (d MentionDetector) Threshold() float64 { return 5.0 / MentionScale }
+
+// Detect returns the number of @username mentions in the text divided by
+// MentionScale.
+func (d MentionDetector) Detect(text string) (float64, string) {
+	return d.DetectMentions(text, 0)
+}
+
+// DetectMentions is like Detect. See HiddenMentionsDetector.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func (d MentionDetector) DetectMentions(text string, hidden int) (float64, string) {
+	mentions := len(mentionRegexp.FindAllString(text, -1)) + hidden
+	if mentions == 0 {
+		return 0, ""
+	}
+	score := float64(mentions) / MentionScale
+	if score > 1 {
+		score = 1
+	}
+	return score, fmt.Sprintf("Too many mentions (%d)", mentions)
+}
+
+// minEmojis is the number of emojis below which EmojiDetector does not trigger,
+// so short reactions (like a single emoji) are accepted.
+const minEmojis = 5
+
+// EmojiDetector is a Detector for messages mostly made of emojis.
+//
+// It implements RawDetector, as emoji sequences are joined by zero-width
+// joiners, that are removed by Normalize.
+type EmojiDetector struct{}
+
+// NewEmojiDetector returns an EmojiDetector.
+func NewEmojiDetector() EmojiDetector {
+	return EmojiDetector{}
+}
+
+// Name returns "emojis".
+func (d EmojiDetector) Name() string { return "emojis" }
+
+// Threshold returns the default threshold: half of the message.
+func (d EmojiDetector) Threshold() float64 { return 0.5 }
+
+// Detect returns the ratio of emojis over the visible chars (spaces, modifiers
+// and invisible chars are not counted). Emoji sequences (joined by zero-width
+// joiners, like families, or pairs of regional indicators, like flags) count as
+// one emoji. Texts with less than minEmojis emojis have score 0. The text must
+// not be normalized.
+func (d EmojiDetector) Detect(text string) (float64, string) {
+	return d.DetectRaw(text)
+}
+
+// DetectRaw is like Detect. See RawDetector.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func (d EmojiDetector) DetectRaw(text string) (float64, string) {
+	var emojis, visible int
+	joined, regional := false, false
+	for _, r := range text {
+		switch {
+		case r == '\u200d':
+			// The next emoji is part of the current sequence.
+			joined = true
+			continue
+		case unicode.IsSpace(r), unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Sk):
+			continue
+		case joined:
+			// Part of the previous emoji.
+		case r >= '\U0001f1e6' && r <= '\U0001f1ff':
+			// Regional indicators are paired in flags.
+			if regional = !regional; regional {
+				emojis++
+				visible++
+			}
+			continue
+		case unicode.Is(unicode.So, r):
+			emojis++
+			visible++
+		default:
+			visible++
+		}
+		joined, regional = false, false
+	}
+	if emojis < minEmojis {
+		return 0, ""
+	}
+	return float64(emojis) / float64(visible), "Emoji wall"
+}
+
+// minCasedLetters is the number of upper or lower case letters below which
+// CapsDetector does not trigger, so short texts (like acronyms) are accepted.
+const minCasedLetters = 15
+
+// CapsDetector is a Detector for messages written in capital letters.
+type CapsDetector struct{}
+
+// NewCapsDetector returns a CapsDetector.
+func NewCapsDetector() CapsDetector {
+	return CapsDetector{}
+}
+
+// Name returns "caps".
+func (d CapsDetector) Name() string { return "caps" }
+
+// Threshold returns the default threshold: 80% of the letters.
+func (d CapsDetector) Threshold() float64 { return 0.8 }
+
+// Detect returns the ratio of upper case letters over all letters with a case.
+// Texts with less than minCasedLetters such letters have score 0.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func (d CapsDetector) Detect(text string) (float64, string) {
+	var upper, cased int
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+			cased++
+		case unicode.IsLower(r):
+			cased++
+		}
+	}
+	if cased < minCasedLetters {
+		return 0, ""
+	}
+	return float64(upper) / float64(cased), "Shouting in capital letters"
+}
+
+// maxStackedMarks is the number of combining marks on the same char that are
+// considered legit (e.g. vowel signs and accents in many scripts).
+const maxStackedMarks = 2
+
+// ZalgoDetector is a Detector for "zalgo" texts, where many combining marks are
+// stacked on the same chars.
+//
+// It implements RawDetector, as combining marks are removed by Normalize.
+type ZalgoDetector struct{}
+
+// NewZalgoDetector returns a ZalgoDetector.
+func NewZalgoDetector() ZalgoDetector {
+	return ZalgoDetector{}
+}
+
+// Name returns "zalgo".
+func (d ZalgoDetector) Name() string { return "zalgo" }
+
+// Threshold returns the default threshold: 10% of the text.
+func (d ZalgoDetector) Threshold() float64 { return 0.1 }
+
+// Detect returns the ratio of stacked combining marks (beyond maxStackedMarks
+// per char) over all chars. The text must not be normalized.
+func (d ZalgoDetector) Detect(text string) (float64, string) {
+	return d.DetectRaw(text)
+}
+
+// DetectRaw is like Detect. See RawDetector.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func (d ZalgoDetector) DetectRaw(text string) (float64, string) {
+	var stacked, total, run int
+	for _, r := range text {
+		total++
+		if unicode.In(r, unicode.Mn, unicode.Me) {
+			run++
+			continue
+		}
+		if run > maxStackedMarks {
+			stacked += run - maxStackedMarks
+		}
+		run = 0
+	}
+	if run > maxStackedMarks {
+		stacked += run - maxStackedMarks
+	}
+	if total == 0 {
+		return 0, ""
+	}
+	return float64(stacked) / float64(total), "Stacked combining marks (zalgo)"
+}
+
+// isBidiOverride returns true for the Unicode left-to-right and right-to-left
+// overrides, used to show text in a different order than the one it is
+// written.
+//
+// Bidirectional embeddings and isolates are not overrides: clients insert them
+// in right-to-left texts, and mixed-direction texts, to display them correctly.
+func isBidiOverride(r rune) bool {
+	return r == '\u202d' || r == '\u202e'
+}
+
+// isZeroWidth returns true for the invisible chars used to split words, so
+// that they pass keyword checks.
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u2060', '\u2061', '\u2062', '\u2063', '\u2064', '\ufeff', '\u180e':
+		return true
+	}
+	return false
+}
+
+// InvisibleDetector is a Detector for texts that use invisible chars to hide
+// their content: right-to-left overrides, and zero-width chars.
+//
+// It implements RawDetector, as invisible chars are removed by Normalize.
+type InvisibleDetector struct{}
+
+// NewInvisibleDetector returns an InvisibleDetector.
+func NewInvisibleDetector() InvisibleDetector {
+	return InvisibleDetector{}
+}
+
+// Name returns "invisible".
+func (d InvisibleDetector) Name() string { return "invisible" }
+
+// Threshold returns the default threshold: 5% of the text.
+func (d InvisibleDetector) Threshold() float64 { return 0.05 }
+
+// Detect returns 1 if the text contains bidirectional overrides, otherwise the
+// ratio of zero-width chars over all chars. The zero-width joiner is not
+// counted, as it is used in emoji sequences, and bidirectional embeddings and
+// isolates are ignored (see isBidiOverride). The text must not be normalized.
+func (d InvisibleDetector) Detect(text string) (float64, string) {
+	return d.DetectRaw(text)
+}
+
+// DetectRaw is like Detect. See RawDetector.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func (d InvisibleDetector) DetectRaw(text string) (float64, string) {
+	var invisible, total int
+	for _, r := range text {
+		if isBidiOverride(r) {
+			return 1, "Right-to-left override"
+		} else if isZeroWidth(r) {
+			invisible++
+		}
+		total++
+	}
+	if total == 0 {
+		return 0, ""
+	}
+	return float64(invisible) / float64(total), "Zero-width characters"
+}
@@ -0,0 +1,110 @@
+package antispam
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// formattingTest is a text with the expected score of a formatting detector.
+type formattingTest struct {
+	name string
+	text string
+	want float64
+}
+
+// runFormattingTests checks the scores of the given detector on the given
+// texts.
+func runFormattingTests(t *testing.T, d RawDetector, tests []formattingTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := d.DetectRaw(tt.text); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("%s.DetectRaw(%q) = %v, want %v", d.Name(), tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionDetector(t *testing.T) {
+	d := NewMentionDetector()
+	tests := []struct {
+		name   string
+		text   string
+		hidden int
+		want   float64
+	}{
+		{"empty", "", 0, 0},
+		{"no mentions", "hello everyone", 0, 0},
+		{"short username", "@abc", 0, 0},
+		{"two mentions", "@alice_1 @bob_22 hello", 0, 2.0 / MentionScale},
+		{"hidden mentions", "hello", 3, 3.0 / MentionScale},
+		{"capped", strings.Repeat("@someone ", 2*MentionScale), 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := d.DetectMentions(tt.text, tt.hidden); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("DetectMentions(%q, %d) = %v, want %v", tt.text, tt.hidden, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmojiDetector(t *testing.T) {
+	family := "👨\u200d👩\u200d👧"
+	runFormattingTests(t, NewEmojiDetector(), []formattingTest{
+		{"empty", "", 0},
+		{"single emoji", "👍", 0},
+		{"emoji wall", "🔥🔥🔥🔥🔥", 1},
+		{"half text", "🔥🔥🔥🔥🔥 hello", 0.5},
+		{"spaces not counted", "🔥 🔥 🔥 🔥 🔥", 1},
+		{"sequences count once", strings.Repeat(family, minEmojis-1), 0},
+		{"sequence wall", strings.Repeat(family, minEmojis), 1},
+		{"flags count once", "🇮🇹🇮🇹🇮🇹", 0},
+		{"flag wall", strings.Repeat("🇮🇹", minEmojis), 1},
+		{"variation selectors", strings.Repeat("⚽️", minEmojis) + "goal", float64(minEmojis) / float64(minEmojis+4)},
+	})
+}
+
+func TestCapsDetector(t *testing.T) {
+	d := NewCapsDetector()
+	tests := []formattingTest{
+		{"empty", "", 0},
+		{"short acronym", "NASA FBI", 0},
+		{"shouting", "THIS IS A SHOUTING MESSAGE!", 1},
+		{"normal", "This is a normal message here", 1.0 / 24},
+		{"numbers not counted", "BUY 1000 COINS NOW OR NEVER", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := d.Detect(tt.text); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Detect(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZalgoDetector(t *testing.T) {
+	runFormattingTests(t, NewZalgoDetector(), []formattingTest{
+		{"empty", "", 0},
+		{"plain", "hello", 0},
+		{"accent", "e\u0301llo", 0},
+		{"legit stacked marks", "e\u0301\u0323llo", 0},
+		{"zalgo", "h\u0300\u0301\u0302\u0303\u0304", 3.0 / 6},
+		{"zalgo at the end", "ab\u0300\u0301\u0302", 1.0 / 5},
+	})
+}
+
+func TestInvisibleDetector(t *testing.T) {
+	runFormattingTests(t, NewInvisibleDetector(), []formattingTest{
+		{"empty", "", 0},
+		{"plain", "hello", 0},
+		{"zero-width space", "he\u200bllo", 1.0 / 6},
+		{"zero-width joiner", "👨\u200d👩\u200d👧", 0},
+		{"right-to-left override", "invoice\u202etxt.exe", 1},
+		{"left-to-right override", "\u202dhello", 1},
+		{"right-to-left isolate", "\u2067שלום\u2069", 0},
+		{"first strong isolate", "ciao \u2068שלום\u2069 a tutti", 0},
+		{"right-to-left embedding", "\u202bمرحبا\u202c", 0},
+	})
+}
@@ -0,0 +1,96 @@
+package antispam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeywordDetector is a Detector for texts containing blocked keywords or
+// matching blocked regular expressions (RE2 syntax).
+type KeywordDetector struct {
+	keywords []string
+	regexps  []*regexp.Regexp
+
+	// index is the position of each rule in the arguments of
+	// NewKeywordDetector: keywords first, then regular expressions
+	index   []int
+	onMatch func(index int, rule string)
+}
+
+// NewKeywordDetector returns a KeywordDetector for the given keywords and
+// regular expressions. Keywords are normalized (see Normalize) and matched
+// case-insensitively anywhere in the text. If onMatch is not nil, it is called
+// with the rule that matched (regular expressions are enclosed in slashes) and
+// its index: the position in keywords, or len(keywords) plus the position in
+// regexps.
+//
+// It returns an error if a regular expression is not valid.
+func NewKeywordDetector(keywords []string, regexps []string, onMatch func(index int, rule string)) (KeywordDetector, error) {
+	d := KeywordDetector{onMatch: onMatch}
+	for i, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(Normalize(keyword)))
+		if keyword != "" {
+			d.keywords = append(d.keywords, keyword)
+			d.index = append(d.index, i)
+		}
+	}
+	for i, expr := range regexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return KeywordDetector{}, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+		d.regexps = append(d.regexps, re)
+		d.index = append(d.index, len(keywords)+i)
+	}
+	return d, nil
+}
+
+// Name returns "keywords".
+func (d KeywordDetector) Name() string { return "keywords" }
+
+// Threshold returns the default threshold. The score is either 0 or 1.
+func (d KeywordDetector) Threshold() float64 { return 0.5 }
+
+// Detect returns 1 if the text contains a keyword or matches a regular
+// expression, 0 otherwise.
+func (d KeywordDetector) Detect(text string) (float64, string) {
+	i, rule := d.match(text)
+	if i < 0 {
+		return 0, ""
+	}
+	if d.onMatch != nil {
+		d.onMatch(d.index[i], rule)
+	}
+	return 1, "Blocked keyword " + rule
+}
+
+// Match returns the first rule that matches the given text. Regular expressions
+// are enclosed in slashes.
+//
+// Time complexity: O(n*k) where "n" is the length of the text and "k" is the
+// number of rules.
+func (d KeywordDetector) Match(text string) (string, bool) {
+	i, rule := d.match(text)
+	return rule, i >= 0
+}
+
+// match is like Match, but it returns the position of the rule in d.index, or
+// -1 if no rule matches.
+func (d KeywordDetector) match(text string) (int, string) {
+	if text == "" {
+		return -1, ""
+	}
+	lower := strings.ToLower(text)
+	for i, keyword := range d.keywords {
+		if strings.Contains(lower, keyword) {
+			return i, keyword
+		}
+	}
+	for i, re := range d.regexps {
+		if re.MatchString(text) {
+			return len(d.keywords) + i, "/" + re.String() + "/"
+		}
+	}
+	return -1, ""
+}
@@ -0,0 +1,47 @@
+package antispam
+
+import "testing"
+
+func TestKeywordDetector(t *testing.T) {
+	type match struct {
+		index int
+		rule  string
+	}
+	var got []match
+	d, err := NewKeywordDetector([]string{"Spam", " ", "ｃｒｙｐｔｏ"}, []string{`^buy\d+$`}, func(index int, rule string) {
+		got = append(got, match{index, rule})
+	})
+	if err != nil {
+		t.Fatalf("NewKeywordDetector: %v", err)
+	}
+
+	tests := []struct {
+		text  string
+		score float64
+		want  match
+	}{
+		{"hello", 0, match{}},
+		{"this is SPAM", 1, match{0, "spam"}},
+		{"free crypto", 1, match{2, "crypto"}},
+		{"buy100", 1, match{3, `/^buy\d+$/`}},
+		{"buy100 now", 0, match{}},
+	}
+	for _, tt := range tests {
+		got = nil
+		score, _ := d.Detect(tt.text)
+		if score != tt.score {
+			t.Errorf("Detect(%q) = %v, want %v", tt.text, score, tt.score)
+		}
+		if tt.score == 0 {
+			if len(got) != 0 {
+				t.Errorf("Detect(%q) called onMatch with %v", tt.text, got)
+			}
+		} else if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("Detect(%q) called onMatch with %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := NewKeywordDetector(nil, []string{"("}, nil); err == nil {
+		t.Errorf("NewKeywordDetector with invalid regexp: error = nil, want error")
+	}
+}
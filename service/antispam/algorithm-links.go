@@ -0,0 +1,120 @@
+package antispam
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlRegexp matches URLs with or without scheme, and bare domains (like
+// "example.com"). The first group is the scheme or the "www." prefix, and the
+// second one is the top-level domain.
+var urlRegexp = regexp.MustCompile(`(?i)((?:https?|tg)://|www\.)?(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+([a-z][a-z0-9-]{1,62})(?::[0-9]{1,5})?(?:/[^\s]*)?`)
+
+// linkTLDs are the top-level domains of bare domains considered links. Without
+// a scheme or "www.", any "x.yy" would be a link (like "node.js", "main.py" or
+// "e.g."), so top-level domains that are common file extensions (like "py",
+// "md", "sh" or "zip") are not in the list.
+var linkTLDs = map[string]bool{
+	"com": true, "net": true, "org": true, "info": true, "biz": true, "io": true,
+	"me": true, "co": true, "xyz": true, "top": true, "site": true, "online": true,
+	"club": true, "app": true, "dev": true, "shop": true, "store": true, "link": true,
+	"live": true, "vip": true, "win": true, "bid": true, "icu": true, "cloud": true,
+	"tech": true, "space": true, "website": true, "fun": true, "pro": true, "tv": true,
+	"cc": true, "ws": true, "ly": true, "gg": true, "tk": true, "ml": true, "ga": true,
+	"cf": true, "gq": true, "su": true, "ru": true, "ua": true, "by": true, "kz": true,
+	"cn": true, "hk": true, "in": true, "br": true, "it": true, "eu": true, "uk": true,
+	"de": true, "fr": true, "es": true, "nl": true, "ch": true, "at": true, "be": true,
+	"us": true, "ca": true, "to": true, "ir": true, "tr": true,
+}
+
+// ExtractURLs returns all URLs and bare domains in the given text. Bare domains
+// must have a well-known top-level domain (see linkTLDs), and hosts after "@"
+// (like in e-mail addresses) are ignored.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func ExtractURLs(text string) []string {
+	var ret []string
+	for _, match := range urlRegexp.FindAllStringSubmatchIndex(text, -1) {
+		if match[0] > 0 && text[match[0]-1] == '@' {
+			continue
+		}
+		if match[2] < 0 && !linkTLDs[strings.ToLower(text[match[4]:match[5]])] {
+			continue
+		}
+		ret = append(ret, text[match[0]:match[1]])
+	}
+	return ret
+}
+
+// URLHost returns the lower-case host name of the given URL or bare domain,
+// without port and without the "www." prefix. It returns an empty string if
+// the URL cannot be parsed.
+func URLHost(rawurl string) string {
+	if !strings.Contains(rawurl, "://") {
+		rawurl = "http://" + rawurl
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.TrimPrefix(host, "www.")
+}
+
+// HostMatches returns true if the given host is one of the given domains, or a
+// sub-domain of one of them.
+//
+// Time complexity: O(n) where "n" is the number of domains.
+func HostMatches(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+		if domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkDetector is a Detector for links to unwanted domains. Links to domains in
+// the allow list are always accepted, links to domains in the deny list are
+// always refused. If blockAll is true, any link not in the allow list is
+// refused.
+type LinkDetector struct {
+	allow    []string
+	deny     []string
+	blockAll bool
+}
+
+// NewLinkDetector returns a LinkDetector with the given allow and deny lists.
+func NewLinkDetector(allow, deny []string, blockAll bool) LinkDetector {
+	return LinkDetector{
+		allow:    allow,
+		deny:     deny,
+		blockAll: blockAll,
+	}
+}
+
+// Name returns "links".
+func (d LinkDetector) Name() string { return "links" }
+
+// Threshold returns the default threshold. The score is either 0 or 1.
+func (d LinkDetector) Threshold() float64 { return 0.5 }
+
+// Detect returns 1 if the text contains a link that is not allowed, 0
+// otherwise.
+func (d LinkDetector) Detect(text string) (float64, string) {
+	for _, link := range ExtractURLs(text) {
+		host := URLHost(link)
+		if host == "" || HostMatches(host, d.allow) {
+			continue
+		}
+		if d.blockAll || HostMatches(host, d.deny) {
+			return 1, "Link to " + host + " not allowed"
+		}
+	}
+	return 0, ""
+}
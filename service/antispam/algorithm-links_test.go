@@ -0,0 +1,95 @@
+package antispam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"scheme", "visit https://example.com/path now", []string{"https://example.com/path"}},
+		{"www", "visit www.example.org", []string{"www.example.org"}},
+		{"bare domain", "visit example.com today", []string{"example.com"}},
+		{"port", "http://example.com:8080/x", []string{"http://example.com:8080/x"}},
+		{"file names", "see main.py and node.js", nil},
+		{"abbreviation", "e.g. this one", nil},
+		{"e-mail", "write to foo@example.com", nil},
+		{"many", "a.com and b.net", []string{"a.com", "b.net"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractURLs(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractURLs(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLHost(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://WWW.Example.com:8080/x", "example.com"},
+		{"example.com/path", "example.com"},
+		{"www.sub.example.org", "sub.example.org"},
+		{"http://[::1", ""},
+	}
+	for _, tt := range tests {
+		if got := URLHost(tt.url); got != tt.want {
+			t.Errorf("URLHost(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	domains := []string{"example.com", " WWW.Other.org ", ""}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"sub.example.com", true},
+		{"badexample.com", false},
+		{"other.org", true},
+		{"example.net", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := HostMatches(tt.host, domains); got != tt.want {
+			t.Errorf("HostMatches(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestLinkDetector(t *testing.T) {
+	allow := []string{"t.me"}
+	deny := []string{"bit.ly"}
+	tests := []struct {
+		name     string
+		blockAll bool
+		text     string
+		want     float64
+	}{
+		{"no links", false, "hello", 0},
+		{"denied", false, "see bit.ly/abc", 1},
+		{"allowed", false, "see t.me/group", 0},
+		{"other", false, "see example.com", 0},
+		{"block all", true, "see example.com", 1},
+		{"block all allowed", true, "see https://t.me/group", 0},
+		{"allowed then denied", false, "t.me/group and bit.ly/abc", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewLinkDetector(allow, deny, tt.blockAll)
+			if got, _ := d.Detect(tt.text); got != tt.want {
+				t.Errorf("Detect(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
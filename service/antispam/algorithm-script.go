@@ -0,0 +1,96 @@
+package antispam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ScriptChars calculate the percent of the string that is in any of the given
+// Unicode scripts (or, more in general, range tables).
+//
+// Time complexity: O(n*t) where "n" is the number of runes in a string and "t"
+// is the number of tables
+func ScriptChars(str string, tables ...*unicode.RangeTable) float64 {
+	// Base: if the string is empty
+	if len(str) == 0 || strings.TrimSpace(str) == "" {
+		return 0
+	}
+
+	// Count runes in string that are in the given scripts
+	badchars := 0
+	totalchars := 0
+	// Note that "totalchars" != len(str), so we need to count runes "manually" using totalchars
+	// The len() function returns the length in byte, but chars might be multi-byte
+	// In fact, Go uses the type "rune" which is more robust (even if there are some "corner cases")
+	for _, runeValue := range str {
+		if unicode.In(runeValue, tables...) {
+			badchars++
+		}
+		totalchars++
+	}
+
+	return float64(badchars) / float64(totalchars)
+}
+
+// ScriptTables returns the Unicode range tables for the given script names, as
+// found in unicode.Scripts (e.g. "Cyrillic", "Han"). It returns an error if a
+// script name is unknown.
+func ScriptTables(names ...string) ([]*unicode.RangeTable, error) {
+	tables := make([]*unicode.RangeTable, 0, len(names))
+	for _, name := range names {
+		table, found := unicode.Scripts[name]
+		if !found {
+			return nil, fmt.Errorf("unknown Unicode script %q", name)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ScriptDetector is a Detector for texts written in a set of Unicode scripts.
+// The score is the percent of chars in any of these scripts.
+type ScriptDetector struct {
+	name      string
+	reason    string
+	threshold float64
+	tables    []*unicode.RangeTable
+}
+
+// NewScriptDetector returns a ScriptDetector with the given name that matches
+// chars in any of the given tables. The reason is reported when the detector
+// triggers.
+func NewScriptDetector(name, reason string, tables ...*unicode.RangeTable) ScriptDetector {
+	return ScriptDetector{
+		name:      name,
+		reason:    reason,
+		threshold: 0.05,
+		tables:    tables,
+	}
+}
+
+// NewScriptDetectorFromNames is like NewScriptDetector, but the tables are
+// looked up by script name in unicode.Scripts. It returns an error if a script
+// name is unknown.
+func NewScriptDetectorFromNames(name string, scripts ...string) (ScriptDetector, error) {
+	tables, err := ScriptTables(scripts...)
+	if err != nil {
+		return ScriptDetector{}, err
+	}
+	sorted := append([]string(nil), scripts...)
+	sort.Strings(sorted)
+	reason := "Script filter enabled (" + strings.Join(sorted, ", ") + ")"
+	return NewScriptDetector(name, reason, tables...), nil
+}
+
+// Name returns the detector name.
+func (d ScriptDetector) Name() string { return d.name }
+
+// Threshold returns the default threshold, 5% of the text.
+func (d ScriptDetector) Threshold() float64 { return d.threshold }
+
+// Detect returns the percent of the text in the detector scripts.
+func (d ScriptDetector) Detect(text string) (float64, string) {
+	return ScriptChars(text, d.tables...), d.reason
+}
@@ -0,0 +1,58 @@
+package antispam
+
+import (
+	"math"
+	"testing"
+	"unicode"
+)
+
+func TestScriptChars(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want float64
+	}{
+		{"empty", "", 0},
+		{"spaces", "   ", 0},
+		{"latin", "hello", 0},
+		{"han", "你好", 1},
+		{"half", "你好ab", 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScriptChars(tt.text, unicode.Han); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ScriptChars(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptTables(t *testing.T) {
+	tables, err := ScriptTables("Cyrillic", "Han")
+	if err != nil {
+		t.Fatalf("ScriptTables: %v", err)
+	}
+	if len(tables) != 2 || tables[0] != unicode.Cyrillic || tables[1] != unicode.Han {
+		t.Errorf("ScriptTables = %v, want Cyrillic and Han", tables)
+	}
+	if _, err := ScriptTables("Cyrillic", "Klingon"); err == nil {
+		t.Errorf("ScriptTables with unknown script: error = nil, want error")
+	}
+}
+
+func TestScriptDetectorFromNames(t *testing.T) {
+	d, err := NewScriptDetectorFromNames("script", "Han", "Cyrillic")
+	if err != nil {
+		t.Fatalf("NewScriptDetectorFromNames: %v", err)
+	}
+	score, reason := d.Detect("привет 你好")
+	if want := 8.0 / 9; math.Abs(score-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", score, want)
+	}
+	if want := "Script filter enabled (Cyrillic, Han)"; reason != want {
+		t.Errorf("reason = %q, want %q", reason, want)
+	}
+	if score, _ := d.Detect("hello"); score != 0 {
+		t.Errorf("score of Latin text = %v, want 0", score)
+	}
+}
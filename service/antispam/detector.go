@@ -0,0 +1,76 @@
+package antispam
+
+import "fmt"
+
+// Detector is implemented by every antispam check. A detector analyzes a text
+// and returns how much that text looks like spam.
+type Detector interface {
+	// Name returns the unique name of the detector. It is used as key for the
+	// per-chat detector settings, so it must never change once released.
+	Name() string
+
+	// Threshold returns the default score above which the text is considered
+	// spam. Chat settings can override it.
+	Threshold() float64
+
+	// Detect returns the spam score for the given text, between 0 (not spam)
+	// and 1 (surely spam), and a human-readable reason for that score.
+	Detect(text string) (score float64, reason string)
+}
+
+// Registry is an ordered set of detectors. Detectors are evaluated in the same
+// order they are registered.
+type Registry struct {
+	detectors []Detector
+	names     map[string]struct{}
+}
+
+// NewRegistry returns a new Registry with the given detectors registered.
+//
+// It panics if two detectors have the same name.
+func NewRegistry(detectors ...Detector) *Registry {
+	r := &Registry{names: make(map[string]struct{})}
+	for _, d := range detectors {
+		if err := r.Register(d); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds the given detector at the end of the registry. It returns an
+// error if a detector with the same name is already registered.
+func (r *Registry) Register(d Detector) error {
+	if _, found := r.names[d.Name()]; found {
+		return fmt.Errorf("detector %q already registered", d.Name())
+	}
+	r.names[d.Name()] = struct{}{}
+	r.detectors = append(r.detectors, d)
+	return nil
+}
+
+// Detectors returns all registered detectors, in registration order.
+func (r *Registry) Detectors() []Detector {
+	return r.detectors
+}
+
+// RawDetector is a Detector that needs the original text, before Normalize.
+// Callers that normalize texts should call DetectRaw with the original text
+// instead of Detect.
+type RawDetector interface {
+	Detector
+
+	// DetectRaw is like Detect, but the text is not normalized.
+	DetectRaw(text string) (score float64, reason string)
+}
+
+// HiddenMentionsDetector is a Detector that counts mentions. Callers that know
+// about mentions that are not in the text (like Telegram text mentions, that
+// link a user on arbitrary text) should call DetectMentions instead of Detect.
+type HiddenMentionsDetector interface {
+	Detector
+
+	// DetectMentions is like Detect, with the given number of mentions not in
+	// the text.
+	DetectMentions(text string, hidden int) (score float64, reason string)
+}
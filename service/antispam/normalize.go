@@ -0,0 +1,161 @@
+package antispam
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps letters of non-Latin scripts to the Latin letter they look
+// like. It is the subset of the Unicode TR39 confusables list (see
+// https://www.unicode.org/Public/security/latest/confusables.txt) for Cyrillic,
+// Greek and Armenian letters, that are the ones used by spammers to write Latin
+// words that pass keyword checks.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j',
+	'ӏ': 'l', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'ѵ': 'v', 'ԝ': 'w',
+	'х': 'x', 'у': 'y',
+	'А': 'A', 'В': 'B', 'С': 'C', 'Ԁ': 'D', 'Е': 'E', 'Н': 'H', 'І': 'I',
+	'Ӏ': 'I', 'Ј': 'J', 'К': 'K', 'М': 'M', 'О': 'O', 'Р': 'P', 'Ԛ': 'Q',
+	'Ѕ': 'S', 'Т': 'T', 'Ԝ': 'W', 'Х': 'X', 'У': 'Y',
+	// Greek
+	'α': 'a', 'ϲ': 'c', 'ι': 'i', 'ϳ': 'j', 'κ': 'k', 'ο': 'o', 'ρ': 'p',
+	'υ': 'u', 'ν': 'v', 'χ': 'x', 'γ': 'y',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M',
+	'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Χ': 'X', 'Υ': 'Y', 'Ζ': 'Z',
+	// Armenian
+	'ց': 'g', 'հ': 'h', 'ո': 'n', 'օ': 'o', 'զ': 'q', 'ս': 'u', 'ա': 'w',
+}
+
+// Normalize returns the text in a form suitable for detectors, folding the
+// tricks used by spammers to hide words:
+//
+//   - compatibility characters (full-width letters, mathematical letters,
+//     ligatures, ...) are folded with NFKC;
+//   - invisible format characters (zero-width spaces and joiners, soft hyphens,
+//     ...) are removed;
+//   - combining marks on Latin, Cyrillic, Greek and common characters are
+//     removed (accented letters that have a precomposed form are kept);
+//   - in words that mix Latin letters with letters of other scripts, the latter
+//     are replaced with the Latin letter they look like (see confusables).
+//
+// Words written only in a non-Latin script are not changed, so texts in other
+// languages are still detected by the script detectors.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func Normalize(text string) string {
+	text = norm.NFKC.String(text)
+
+	ret := strings.Builder{}
+	ret.Grow(len(text))
+	var word []rune
+	flush := func() {
+		if isMixedScriptWord(word) {
+			for i, r := range word {
+				if l, found := confusables[r]; found {
+					word[i] = l
+				}
+			}
+		}
+		ret.WriteString(string(word))
+		word = word[:0]
+	}
+
+	var base rune
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cf, r):
+			// Invisible format characters are dropped.
+			continue
+		case unicode.Is(unicode.Mn, r):
+			if base == 0 || unicode.In(base, unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Common) {
+				continue
+			}
+			word = append(word, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			base = r
+			word = append(word, r)
+		default:
+			base = r
+			flush()
+			ret.WriteRune(r)
+		}
+	}
+	flush()
+	return ret.String()
+}
+
+// isMixedScriptWord returns true if the word contains both Latin letters and
+// letters of other scripts that look like Latin letters.
+func isMixedScriptWord(word []rune) bool {
+	var latin, confusable bool
+	for _, r := range word {
+		if unicode.Is(unicode.Latin, r) {
+			latin = true
+		} else if _, found := confusables[r]; found {
+			confusable = true
+		}
+		if latin && confusable {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfusableDetector is a Detector for texts mostly made of words that mix Latin
+// letters with look-alike letters of other scripts (e.g. "frее" with Cyrillic
+// "е"), a common trick to evade keyword checks.
+//
+// It implements RawDetector, as the mixed-script words are removed by
+// Normalize.
+type ConfusableDetector struct{}
+
+// NewConfusableDetector returns a ConfusableDetector.
+func NewConfusableDetector() ConfusableDetector {
+	return ConfusableDetector{}
+}
+
+// Name returns "confusables".
+func (d ConfusableDetector) Name() string { return "confusables" }
+
+// Threshold returns the default threshold: half of the letters.
+func (d ConfusableDetector) Threshold() float64 { return 0.5 }
+
+// Detect returns the ratio of letters in mixed-script words over all letters.
+// The text must not be normalized.
+func (d ConfusableDetector) Detect(text string) (float64, string) {
+	return d.DetectRaw(text)
+}
+
+// DetectRaw is like Detect. See RawDetector.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func (d ConfusableDetector) DetectRaw(text string) (float64, string) {
+	var letters, mixed int
+	var word []rune
+	flush := func() {
+		if isMixedScriptWord(word) {
+			mixed += len(word)
+		}
+		letters += len(word)
+		word = word[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cf, r), unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	if letters == 0 {
+		return 0, ""
+	}
+	return float64(mixed) / float64(letters), "Mixed-script look-alike letters"
+}
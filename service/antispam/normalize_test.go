@@ -0,0 +1,58 @@
+package antispam
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", ""},
+		{"plain", "free money", "free money"},
+		{"full-width", "ｆｒｅｅ", "free"},
+		{"mathematical letters", "𝐟𝐫𝐞𝐞", "free"},
+		{"zero-width space", "fr\u200bee", "free"},
+		{"soft hyphen", "fr\u00adee", "free"},
+		{"precomposed accent", "caffè", "caffè"},
+		{"decomposed accent", "caffe\u0300", "caffè"},
+		{"stacked marks", "h\u0300\u0301ello", "hello"},
+		{"cyrillic confusable", "fr\u0435\u0435", "free"},
+		{"greek confusable", "\u0397ello", "Hello"},
+		{"cyrillic word", "привет мир", "привет мир"},
+		{"mixed sentence", "fr\u0435\u0435 money привет", "free money привет"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfusableDetector(t *testing.T) {
+	d := NewConfusableDetector()
+	tests := []struct {
+		name string
+		text string
+		want float64
+	}{
+		{"empty", "", 0},
+		{"latin", "free money", 0},
+		{"cyrillic", "привет мир", 0},
+		{"all mixed", "fr\u0435\u0435 m\u043en\u0435y", 1},
+		{"partly mixed", "fr\u0435\u0435 money", 4.0 / 9},
+		{"zero-width inside word", "fr\u200b\u0435\u0435", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := d.DetectRaw(tt.text); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("DetectRaw(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package antispam
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// mentionRegexp matches Telegram @mentions (usernames are 5-32 chars long).
+var mentionRegexp = regexp.MustCompile(`@[A-Za-z0-9_]{5,32}`)
+
+// HasMention returns true if the given text contains a Telegram @mention.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func HasMention(text string) bool {
+	return mentionRegexp.MatchString(text)
+}
+
+// HasLinkOrMention returns true if the given text contains a URL, a bare domain
+// or a Telegram @mention. Spammers put them in names to advertise channels.
+//
+// Time complexity: O(n) where "n" is the length of the text.
+func HasLinkOrMention(text string) bool {
+	return len(ExtractURLs(text)) > 0 || HasMention(text)
+}
+
+// IsSymbolsOnly returns true if the given text is not empty and it has no
+// letters and no digits (e.g. names made only of emojis).
+//
+// Time complexity: O(n) where "n" is the number of runes in the text.
+func IsSymbolsOnly(text string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
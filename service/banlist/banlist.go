@@ -0,0 +1,143 @@
+// Package banlist loads lists of banned Telegram user IDs published by
+// anti-spam communities (like CAS, see the cas package), and keeps them updated
+// in background.
+package banlist
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BanListProvider is a list of banned users.
+//
+// All methods are safe for concurrent use.
+type BanListProvider interface {
+	// Name returns the unique name of the list. It is used as key for the
+	// per-chat actions, so it must never change.
+	Name() string
+
+	// Load manually retrieve the list and loads it in memory, replacing the
+	// current list. It can be used when the auto-updater is disabled (see New
+	// function).
+	Load() error
+
+	// IsBanned returns true if the given Telegram's ID is present in the list.
+	IsBanned(id int64) bool
+
+	// Updated returns the time of the last update of the loaded list, or the
+	// zero time if no list is loaded.
+	Updated() time.Time
+
+	// Stats returns the statistics of the updates of the list.
+	Stats() Stats
+
+	// Close unloads the list and stops the auto-updater worker, if started. It
+	// returns when the worker is stopped.
+	Close() error
+}
+
+// Checker is implemented by lists that can check a single user on demand (e.g.
+// with a query to a remote service), instead of waiting for the next update
+// of the whole list.
+type Checker interface {
+	// Check is like IsBanned, but it may query the remote service. As it is
+	// slower, it should be used only when a user is first seen.
+	Check(id int64) bool
+}
+
+// Providers merges several lists into one lookup.
+type Providers []BanListProvider
+
+// Lookup returns the lists where the given Telegram's ID is present, in order.
+func (p Providers) Lookup(id int64) []BanListProvider {
+	var ret []BanListProvider
+	for _, provider := range p {
+		if provider.IsBanned(id) {
+			ret = append(ret, provider)
+		}
+	}
+	return ret
+}
+
+// Check is like Lookup, but it uses Checker.Check for lists that implement it.
+func (p Providers) Check(id int64) []BanListProvider {
+	var ret []BanListProvider
+	for _, provider := range p {
+		var banned bool
+		if checker, ok := provider.(Checker); ok {
+			banned = checker.Check(id)
+		} else {
+			banned = provider.IsBanned(id)
+		}
+		if banned {
+			ret = append(ret, provider)
+		}
+	}
+	return ret
+}
+
+// snapshot is a loaded list. It is never modified after creation: a new load
+// creates a new snapshot and swaps it atomically.
+type snapshot struct {
+	ids     map[int64]struct{}
+	updated time.Time
+}
+
+// list is the concrete type that implements BanListProvider, downloading the
+// list from an URL.
+type list struct {
+	name     string
+	url      string
+	format   Format
+	column   string
+	interval time.Duration
+	nonEmpty bool
+
+	c      *http.Client
+	db     atomic.Value // Current *snapshot.
+	store  Store
+	logger logrus.FieldLogger
+
+	// stats are the update statistics, except the ones of the snapshot.
+	statsMu sync.Mutex
+	stats   Stats
+
+	// stop cancels the context of the worker (if started), and done is closed
+	// when the worker returns.
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// Name returns the name of the list.
+func (l *list) Name() string {
+	return l.name
+}
+
+// snapshot returns the current snapshot.
+func (l *list) snapshot() *snapshot {
+	return l.db.Load().(*snapshot)
+}
+
+// IsBanned returns true if the given Telegram's ID is present in the list.
+func (l *list) IsBanned(uid int64) bool {
+	_, found := l.snapshot().ids[uid]
+	return found
+}
+
+// Updated returns the time of the last update of the loaded list.
+func (l *list) Updated() time.Time {
+	return l.snapshot().updated
+}
+
+// Close unloads the list and stops the auto-updater worker, if started.
+func (l *list) Close() error {
+	l.stop()
+	<-l.done
+	l.db.Store(&snapshot{})
+	return nil
+}
@@ -0,0 +1,104 @@
+package banlist
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the format of a list.
+type Format string
+
+const (
+	// FormatLines is a list of user IDs, one per line (like the CAS export)
+	FormatLines Format = "lines"
+
+	// FormatCSV is a CSV file with user IDs in a column
+	FormatCSV Format = "csv"
+
+	// FormatJSON is a JSON array of user IDs, as numbers or strings
+	FormatJSON Format = "json"
+)
+
+// parse reads a list in the given format (see parseLines for FormatLines). For
+// FormatCSV, the user IDs are in the given column (see Options). Rows that are
+// not user IDs are skipped, and their number is returned.
+func parse(r io.Reader, format Format, column string, updated time.Time) (*snapshot, int, error) {
+	s := &snapshot{ids: make(map[int64]struct{}), updated: updated}
+	skipped := 0
+	add := func(row string) {
+		if uid, err := strconv.ParseInt(strings.TrimSpace(row), 10, 64); err != nil {
+			skipped++
+		} else {
+			s.ids[uid] = struct{}{}
+		}
+	}
+
+	switch format {
+	case FormatLines:
+		return parseLines(r, updated)
+
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		idx := 0
+		if column != "" {
+			header, err := reader.Read()
+			if err != nil {
+				return nil, skipped, fmt.Errorf("error reading CSV header: %w", err)
+			}
+			idx = -1
+			for i, name := range header {
+				if strings.TrimSpace(name) == column {
+					idx = i
+				}
+			}
+			if idx < 0 {
+				return nil, skipped, fmt.Errorf("column %q not found in CSV header", column)
+			}
+		}
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, skipped, err
+			}
+			if len(record) > 0 && strings.Contains(record[0], "cloudflare") {
+				return nil, skipped, ErrCloudflareLimited
+			}
+			if idx >= len(record) {
+				skipped++
+				continue
+			}
+			add(record[idx])
+		}
+
+	case FormatJSON:
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		var ids []interface{}
+		if err := decoder.Decode(&ids); err != nil {
+			return nil, skipped, fmt.Errorf("error decoding JSON array: %w", err)
+		}
+		for _, id := range ids {
+			switch v := id.(type) {
+			case json.Number:
+				add(v.String())
+			case string:
+				add(v)
+			default:
+				skipped++
+			}
+		}
+
+	default:
+		return nil, skipped, fmt.Errorf("unknown format %q", format)
+	}
+	return s, skipped, nil
+}
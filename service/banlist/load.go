@@ -0,0 +1,105 @@
+package banlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrTimeout           = errors.New("ban list download error: timeout")
+	ErrCloudflareLimited = errors.New("ban list download error: CloudFlare limited")
+	ErrTruncated         = errors.New("ban list download error: truncated")
+	ErrEmpty             = errors.New("ban list download error: empty")
+)
+
+// Load manually retrieve the datatabase from the list URL and loads it in
+// memory, replacing the current database. It can be used when the auto-updater
+// is disabled (see New function)
+func (l *list) Load() error {
+	return l.load(context.Background())
+}
+
+// load is like Load, but the download is aborted when the given context is
+// canceled. The result is recorded in the statistics, unless the context is
+// canceled.
+func (l *list) load(ctx context.Context) error {
+	start := time.Now()
+	err := l.download(ctx)
+	if ctx.Err() == nil {
+		l.recordUpdate(start, err)
+	}
+	return err
+}
+
+// download retrieves the database and loads it.
+//
+// The new database replaces the current one only if it looks complete: if it
+// is empty and the list is never empty (see Options.NonEmpty), or if it is much
+// smaller than the current one (e.g. a truncated download), the current one is
+// kept and ErrEmpty or ErrTruncated is returned. Otherwise, the new database is
+// saved in the store, if any.
+func (l *list) download(ctx context.Context) error {
+	// Retrieve the current database in the list format.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url, nil)
+	if err != nil {
+		return err
+	}
+	// We need to say to Cloudflare that we're somehow a legit browser
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:68.0) Gecko/20100101 Firefox/68.0")
+
+	resp, err := l.c.Do(req)
+	if err != nil {
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			l.logger.WithError(err).Warning("Ban list download timeout")
+			return ErrTimeout
+		}
+		l.logger.WithError(err).Warning("Failed to download ban list")
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		l.logger.WithField("http-status", resp.StatusCode).Error("Unexpected HTTP status during ban list download")
+		return fmt.Errorf("ban list download error: HTTP status %d", resp.StatusCode)
+	}
+
+	// We calculate the new snapshot as separate entity, because if the list
+	// is empty (due to upstream error) we can keep old values
+	newlist, skipped, err := parse(resp.Body, l.format, l.column, time.Now())
+	if errors.Is(err, ErrCloudflareLimited) {
+		l.logger.Warning("Ban list download limited by cloudflare")
+		return err
+	} else if err != nil {
+		l.logger.WithError(err).Warning("Failed to read ban list")
+		return err
+	}
+	if skipped > 0 {
+		l.logger.WithField("rows", skipped).Error("Failed to convert IDs to Telegram UID")
+	}
+
+	// If the new database is empty (for lists that are never empty), or less
+	// than half of the old one, it's likely an upstream error: keep the old one
+	current := l.snapshot()
+	if len(newlist.ids) == 0 && l.nonEmpty {
+		l.logger.Warning("New ban list is empty, keeping old values")
+		return ErrEmpty
+	} else if len(newlist.ids) < len(current.ids)/2 {
+		l.logger.WithField("items", len(newlist.ids)).WithField("current", len(current.ids)).Warning("New ban list is truncated, keeping old values")
+		return ErrTruncated
+	}
+
+	// Use the new database and discard the old one. Readers still using the old
+	// snapshot are not affected.
+	l.db.Store(newlist)
+	l.logger.WithField("items", len(newlist.ids)).Debug("Ban list updated")
+
+	if l.store != nil {
+		if err := l.store.SetBanListSnapshot(l.name, newlist.encode(), newlist.updated); err != nil {
+			l.logger.WithError(err).Error("Failed to save ban list")
+		}
+	}
+	return nil
+}
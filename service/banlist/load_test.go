@@ -0,0 +1,50 @@
+package banlist
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoadEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name     string
+		nonEmpty bool
+		want     error
+	}{
+		{"can be empty", false, nil},
+		{"never empty", true, ErrEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := New(Options{
+				Name:     "test",
+				URL:      server.URL,
+				Format:   FormatLines,
+				NonEmpty: tt.nonEmpty,
+				Logger:   logger,
+			})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			t.Cleanup(func() { _ = list.Close() })
+
+			if err := list.Load(); !errors.Is(err, tt.want) {
+				t.Errorf("Load error = %v, want %v", err, tt.want)
+			}
+			stats := list.Stats()
+			if failures := stats.Failures; (failures != 0) != (tt.want != nil) {
+				t.Errorf("Failures = %d, want failure %v", failures, tt.want != nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package banlist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// nameRegexp matches the valid list names: they are used in database keys,
+// metrics and settings, so they are short and simple.
+var nameRegexp = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Options are the options for New.
+type Options struct {
+	// Name is the unique name of the list (see BanListProvider). It is made
+	// of 1 to 32 lower case letters, digits, "_" and "-"
+	Name string
+
+	// URL is the address of the list
+	URL string
+
+	// Format is the format of the list
+	Format Format
+
+	// Column is the name of the column with user IDs, for FormatCSV. If empty,
+	// user IDs are in the first column
+	Column string
+
+	// Interval is the time between two updates. Zero means one hour
+	Interval time.Duration
+
+	// NonEmpty is true if the list is never empty (like CAS): an empty
+	// download is an upstream error, and the update fails with ErrEmpty
+	NonEmpty bool
+
+	// AutoUpdate launches a goroutine that updates the list in background
+	AutoUpdate bool
+
+	// Logger is used for debug
+	Logger logrus.FieldLogger
+
+	// Client overrides the default HTTP client, if not nil
+	Client *http.Client
+
+	// Store saves the last good list, if not nil. The saved list is loaded at
+	// startup, unless File is set
+	Store Store
+
+	// File is a list file in the list format loaded at startup, if not empty
+	// (e.g. for deployments without Internet access)
+	File string
+}
+
+// New returns a BanListProvider that downloads the list from the URL in
+// options.
+//
+// The initial list is loaded from the file or from the store in options, so
+// it's available before the first download.
+func New(opts Options) (BanListProvider, error) {
+	if !nameRegexp.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid ban list name %q: use 1 to 32 lower case letters, digits, \"_\" and \"-\"", opts.Name)
+	}
+	switch opts.Format {
+	case FormatLines, FormatCSV, FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown format %q for ban list %q", opts.Format, opts.Name)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 1 * time.Minute}
+	}
+	interval := opts.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	l := list{
+		name:     opts.Name,
+		url:      opts.URL,
+		format:   opts.Format,
+		column:   opts.Column,
+		interval: interval,
+		nonEmpty: opts.NonEmpty,
+		c:        client,
+		store:    opts.Store,
+		logger:   opts.Logger.WithField("banlist", opts.Name),
+		stop:     stop,
+		done:     make(chan struct{}),
+	}
+	l.db.Store(&snapshot{})
+
+	if opts.File != "" {
+		if err := l.loadFile(opts.File); err != nil {
+			stop()
+			return nil, err
+		}
+	} else if l.store != nil {
+		if err := l.loadStore(); err != nil {
+			// Not fatal: the list will be downloaded.
+			l.logger.WithError(err).Warning("Failed to load saved ban list")
+		}
+	}
+
+	if opts.AutoUpdate {
+		go l.worker(ctx)
+	} else {
+		close(l.done)
+	}
+	return &l, nil
+}
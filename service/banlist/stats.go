@@ -0,0 +1,74 @@
+package banlist
+
+import (
+	"errors"
+	"time"
+)
+
+// Stats are the statistics of the updates of a list.
+type Stats struct {
+	// Items is the number of IDs in the loaded list
+	Items int
+
+	// Updated is the time of the loaded list (see BanListProvider.Updated)
+	Updated time.Time
+
+	// Duration is the time elapsed for the last successful update (download
+	// and parsing)
+	Duration time.Duration
+
+	// LastAttempt is the time of the last update, successful or not
+	LastAttempt time.Time
+
+	// ConsecutiveFailures is the number of failed updates since the last
+	// successful one
+	ConsecutiveFailures int
+
+	// LastError is the error of the last update, nil if it was successful
+	LastError error
+
+	// Failures is the total number of failed updates, including the ones
+	// counted in Timeouts and CloudflareLimited
+	Failures uint64
+
+	// Timeouts is the total number of updates failed with ErrTimeout
+	Timeouts uint64
+
+	// CloudflareLimited is the total number of updates failed with
+	// ErrCloudflareLimited
+	CloudflareLimited uint64
+}
+
+// Stats returns the statistics of the updates of the list.
+func (l *list) Stats() Stats {
+	l.statsMu.Lock()
+	ret := l.stats
+	l.statsMu.Unlock()
+
+	current := l.snapshot()
+	ret.Items = len(current.ids)
+	ret.Updated = current.updated
+	return ret
+}
+
+// recordUpdate updates the statistics with the result of an update started at
+// the given time.
+func (l *list) recordUpdate(start time.Time, err error) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.stats.LastAttempt = start
+	l.stats.LastError = err
+	if err == nil {
+		l.stats.Duration = time.Since(start)
+		l.stats.ConsecutiveFailures = 0
+		return
+	}
+	l.stats.ConsecutiveFailures++
+	l.stats.Failures++
+	if errors.Is(err, ErrTimeout) {
+		l.stats.Timeouts++
+	} else if errors.Is(err, ErrCloudflareLimited) {
+		l.stats.CloudflareLimited++
+	}
+}
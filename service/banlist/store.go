@@ -0,0 +1,107 @@
+package banlist
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store persists the last good databases of the lists, so they are available at
+// startup before the first download (or if downloads fail).
+type Store interface {
+	// SetBanListSnapshot saves the given database, in FormatLines, and the
+	// time of its download.
+	SetBanListSnapshot(name string, data []byte, updated time.Time) error
+
+	// GetBanListSnapshot returns the saved database and the time of its
+	// download. It returns nil data if no database was saved.
+	GetBanListSnapshot(name string) ([]byte, time.Time, error)
+}
+
+// parseLines reads a database in FormatLines (a list of user IDs, one per
+// line). Rows that are not user IDs are skipped, and their number is returned.
+func parseLines(r io.Reader, updated time.Time) (*snapshot, int, error) {
+	s := &snapshot{ids: make(map[int64]struct{}), updated: updated}
+	skipped := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		row := strings.TrimSpace(scanner.Text())
+		// Empty line, skipping
+		if row == "" {
+			continue
+		}
+
+		// Cloudflare CDN is used to distribute the CSV. If Cloudflare somehow
+		// "detects" a strange connection, they truncate the file and they put a
+		// message there
+		if strings.Contains(row, "cloudflare") {
+			return nil, skipped, ErrCloudflareLimited
+		}
+
+		// Try to parse the row as user ID (integer)
+		if uid, err := strconv.ParseInt(row, 10, 64); err != nil {
+			skipped++
+		} else {
+			s.ids[uid] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, skipped, err
+	}
+	return s, skipped, nil
+}
+
+// encode returns the snapshot in FormatLines.
+func (s *snapshot) encode() []byte {
+	buf := make([]byte, 0, len(s.ids)*11)
+	for uid := range s.ids {
+		buf = strconv.AppendInt(buf, uid, 10)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// loadFile loads the database from the given file in the list format. The
+// modification time of the file is the update time.
+func (l *list) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	s, _, err := parse(f, l.format, l.column, info.ModTime())
+	if err != nil {
+		return fmt.Errorf("error reading ban list file %q: %w", path, err)
+	}
+	l.db.Store(s)
+	l.logger.WithField("items", len(s.ids)).WithField("file", path).Info("Ban list loaded from file")
+	return nil
+}
+
+// loadStore loads the database saved in the store, if any.
+func (l *list) loadStore() error {
+	data, updated, err := l.store.GetBanListSnapshot(l.name)
+	if err != nil {
+		return err
+	} else if data == nil {
+		return nil
+	}
+	s, _, err := parseLines(bytes.NewReader(data), updated)
+	if err != nil {
+		return fmt.Errorf("error reading saved ban list: %w", err)
+	}
+	l.db.Store(s)
+	l.logger.WithField("items", len(s.ids)).WithField("updated", updated).Info("Ban list loaded from store")
+	return nil
+}
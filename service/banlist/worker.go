@@ -0,0 +1,51 @@
+package banlist
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// defaultInterval is the time between two database updates, if not set in
+	// options.
+	defaultInterval = 1 * time.Hour
+
+	// minRetryDelay is the delay before retrying a failed update. It doubles at
+	// each failure, up to the update interval.
+	minRetryDelay = 30 * time.Second
+)
+
+// worker updates the database every interval, retrying failed updates
+// with exponential backoff, until the given context is canceled. If the loaded
+// database is recent (e.g. from the store), the first update is delayed.
+func (l *list) worker(ctx context.Context) {
+	defer close(l.done)
+
+	delay := time.Until(l.Updated().Add(l.interval))
+	retryDelay := minRetryDelay
+	for {
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+
+		delay = l.interval
+		if err := l.load(ctx); err == nil {
+			retryDelay = minRetryDelay
+		} else if ctx.Err() != nil {
+			return
+		} else {
+			delay = retryDelay
+			l.logger.WithError(err).WithField("retry", delay).Warning("Ban list update failed")
+			retryDelay *= 2
+			if retryDelay > l.interval {
+				retryDelay = l.interval
+			}
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package bot
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	tb "gopkg.in/telebot.v3"
 )
 
-// banUser will ban a user. It has no effect on chat admins. It records the action in the log
-func (bot *telegramBot) banUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, reason string) {
+// banUser will ban a user for the given duration (zero means forever). It has no effect on chat admins. It records the
+// action in the log, and returns the Telegram error, if any
+func (bot *telegramBot) banUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, duration time.Duration, reason string) error {
 	logfields := logrus.Fields{
 		"userid": user.ID,
 		"chatid": chat.ID,
@@ -14,21 +17,24 @@ func (bot *telegramBot) banUser(chat *tb.Chat, user *tb.User, chatsettings chatS
 
 	// If the user is an admin, be polite (remember: The Admin Is Always Right®)
 	if chatsettings.ChatAdmins.IsAdmin(user) {
-		return
+		return nil
 	}
 
 	member, err := bot.telebot.ChatMemberOf(chat, user)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("ban action: cannot get member object for user")
-		return
+		return err
 	}
 
+	until := restrictionEnd(duration)
+	member.RestrictedUntil = until
 	err = bot.telebot.Ban(chat, member)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("ban action: cannot ban user")
-		return
+		return err
 	}
 
-	bot.logger.WithFields(logfields).WithField("reason", reason).Info("ban user")
-	chatsettings.Log("ban", bot.telebot.Me, user, reason)
+	bot.logger.WithFields(logfields).WithField("reason", reason).WithField("until", until).Info("ban user")
+	chatsettings.Log("ban", bot.telebot.Me, user, restrictionDetails(reason, until))
+	return nil
 }
@@ -6,8 +6,8 @@ import (
 )
 
 // deleteMessage is useful when deleting a message that needs to be recorded in the log (e.g. a non-system message). It
-// has no effect on admins
-func (bot *telegramBot) deleteMessage(m *tb.Message, chatsettings chatSettings, reason string) {
+// has no effect on admins. It returns the Telegram error, if any
+func (bot *telegramBot) deleteMessage(m *tb.Message, chatsettings chatSettings, reason string) error {
 	logfields := logrus.Fields{
 		"userid":    m.Sender.ID,
 		"chatid":    m.Chat.ID,
@@ -16,7 +16,7 @@ func (bot *telegramBot) deleteMessage(m *tb.Message, chatsettings chatSettings,
 
 	// If the user is an admin, be polite (remember: The Admin Is Always Right®)
 	if chatsettings.ChatAdmins.IsAdmin(m.Sender) {
-		return
+		return nil
 	}
 
 	chatsettings.Log("delete message", bot.telebot.Me, m.Sender, reason)
@@ -25,6 +25,8 @@ func (bot *telegramBot) deleteMessage(m *tb.Message, chatsettings chatSettings,
 	err := bot.telebot.Delete(m)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("delete msg action: can't delete message")
+		return err
 	}
 	bot.logger.WithFields(logfields).WithField("reason", reason).Info("delete message")
+	return nil
 }
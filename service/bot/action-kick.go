@@ -7,8 +7,9 @@ import (
 
 // kickUser kicks the given user on the given chat.
 //
-// It has no effect on chat admins. It records the action in the log.
-func (bot *telegramBot) kickUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, reason string) {
+// It has no effect on chat admins. It records the action in the log, and returns
+// the Telegram error, if any.
+func (bot *telegramBot) kickUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, reason string) error {
 	logfields := logrus.Fields{
 		"userid": user.ID,
 		"chatid": chat.ID,
@@ -16,13 +17,13 @@ func (bot *telegramBot) kickUser(chat *tb.Chat, user *tb.User, chatsettings chat
 
 	// If the user is an admin, be polite (remember: The Admin Is Always Right®)
 	if chatsettings.ChatAdmins.IsAdmin(user) {
-		return
+		return nil
 	}
 
 	member, err := bot.telebot.ChatMemberOf(chat, user)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("kick action: failed to get member object for user")
-		return
+		return err
 	}
 
 	// There is no method for kicking a user in Telegram. Banning and un-banning
@@ -30,14 +31,15 @@ func (bot *telegramBot) kickUser(chat *tb.Chat, user *tb.User, chatsettings chat
 	err = bot.telebot.Ban(chat, member)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("kick action: failed to ban user")
-		return
+		return err
 	}
 
 	err = bot.telebot.Unban(chat, user)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("kick action: failed to unban user")
-		return
+		return err
 	}
 	bot.logger.WithFields(logfields).WithField("reason", reason).Info("kick user")
 	chatsettings.Log("kick", bot.telebot.Me, user, reason)
+	return nil
 }
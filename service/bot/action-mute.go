@@ -1,14 +1,18 @@
 package bot
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	tb "gopkg.in/telebot.v3"
 )
 
-// muteUser mutes the given user on the given chat.
+// muteUser mutes the given user on the given chat for the given duration (zero
+// means forever).
 //
-// It has no effect on chat admins. It records the action in the log.
-func (bot *telegramBot) muteUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, reason string) {
+// It has no effect on chat admins. It records the action in the log, and returns
+// the Telegram error, if any.
+func (bot *telegramBot) muteUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, duration time.Duration, reason string) error {
 	logfields := logrus.Fields{
 		"userid": user.ID,
 		"chatid": chat.ID,
@@ -16,24 +20,27 @@ func (bot *telegramBot) muteUser(chat *tb.Chat, user *tb.User, chatsettings chat
 
 	// If the user is an admin, be polite (remember: The Admin Is Always Right®)
 	if chatsettings.ChatAdmins.IsAdmin(user) {
-		return
+		return nil
 	}
 
 	member, err := bot.telebot.ChatMemberOf(chat, user)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("mute action: failed to get member object for user")
-		return
+		return err
 	}
 
 	member.CanSendMedia = false
 	member.CanSendMessages = false
 	member.CanSendOther = false
+	until := restrictionEnd(duration)
+	member.RestrictedUntil = until
 	err = bot.telebot.Restrict(chat, member)
 	if err != nil {
 		bot.logger.WithError(err).WithFields(logfields).Error("mute action: failed to save member restriction")
-		return
+		return err
 	}
 
-	bot.logger.WithFields(logfields).WithField("reason", reason).Info("mute user")
-	chatsettings.Log("mute", bot.telebot.Me, user, reason)
+	bot.logger.WithFields(logfields).WithField("reason", reason).WithField("until", until).Info("mute user")
+	chatsettings.Log("mute", bot.telebot.Me, user, restrictionDetails(reason, until))
+	return nil
 }
@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// unbanUser removes the ban of the given user on the given chat. The user is
+// not added back to the chat, but can join again. Users that are not banned are
+// left untouched.
+//
+// It records the action in the log, and returns the Telegram error, if any.
+func (bot *telegramBot) unbanUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, reason string) error {
+	logfields := logrus.Fields{
+		"userid": user.ID,
+		"chatid": chat.ID,
+	}
+
+	err := bot.telebot.Unban(chat, user, true)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("unban action: failed to unban user")
+		return err
+	}
+
+	bot.logger.WithFields(logfields).WithField("reason", reason).Info("unban user")
+	chatsettings.Log("unban", bot.telebot.Me, user, reason)
+	return nil
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// unrestrictUser removes all restrictions (e.g. a mute) of the given user on the
+// given chat. Users that are not restricted are left untouched.
+//
+// It records the action in the log, and returns the Telegram error, if any.
+func (bot *telegramBot) unrestrictUser(chat *tb.Chat, user *tb.User, chatsettings chatSettings, reason string) error {
+	logfields := logrus.Fields{
+		"userid": user.ID,
+		"chatid": chat.ID,
+	}
+
+	member, err := bot.telebot.ChatMemberOf(chat, user)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("unrestrict action: failed to get member object for user")
+		return err
+	}
+	if member.Role != tb.Restricted {
+		return nil
+	}
+
+	member.Rights = tb.NoRestrictions()
+	member.RestrictedUntil = tb.Forever()
+	err = bot.telebot.Restrict(chat, member)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("unrestrict action: failed to remove member restriction")
+		return err
+	}
+
+	bot.logger.WithFields(logfields).WithField("reason", reason).Info("unrestrict user")
+	chatsettings.Log("unrestrict", bot.telebot.Me, member.User, reason)
+	return nil
+}
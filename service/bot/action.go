@@ -1,19 +1,39 @@
 package bot
 
 import (
+	"fmt"
+	"time"
+
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
 	tb "gopkg.in/telebot.v3"
 )
 
 // performAction is a multiplexer function used to do an action (muteUser, banUser, kickUser, deleteMessage) based on
-// the chat settings
+// the chat settings. If strikes are enabled in the chat, the action is replaced by the one for the user strike level
+// (see strikes.go)
 func (bot *telegramBot) performAction(message *tb.Message, user *tb.User, settings chatSettings, action database.BotAction, reason string) {
+	if settings.Strikes.Enabled && action.Action != database.ActionNone && !settings.ChatAdmins.IsAdmin(user) {
+		bot.performStrike(message, user, settings, reason)
+		return
+	}
+	bot.enforceAction(message, user, settings, action, reason)
+}
+
+// enforceAction is like performAction, but it always does the given action, regardless of strikes. Use this for
+// actions that must not be softened (e.g. on users in blacklists). Actions with a delay are scheduled (see
+// scheduler.go)
+func (bot *telegramBot) enforceAction(message *tb.Message, user *tb.User, settings chatSettings, action database.BotAction, reason string) {
+	if action.Delay > 0 {
+		bot.scheduleAction(message, user, action, reason)
+		return
+	}
+
 	switch action.Action {
 	case database.ActionMute:
-		bot.muteUser(message.Chat, user, settings, reason)
+		bot.muteUser(message.Chat, user, settings, time.Duration(action.Duration)*time.Second, reason)
 		bot.deleteMessage(message, settings, reason)
 	case database.ActionBan:
-		bot.banUser(message.Chat, user, settings, reason)
+		bot.banUser(message.Chat, user, settings, time.Duration(action.Duration)*time.Second, reason)
 		bot.deleteMessage(message, settings, reason)
 	case database.ActionKick:
 		bot.kickUser(message.Chat, user, settings, reason)
@@ -24,3 +44,21 @@ func (bot *telegramBot) performAction(message *tb.Message, user *tb.User, settin
 	default:
 	}
 }
+
+// restrictionEnd returns the unix time when a restriction of the given duration
+// ends, for Telegram "until_date" parameters. Zero means forever.
+func restrictionEnd(duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+	return time.Now().Add(duration).Unix()
+}
+
+// restrictionDetails returns the description of a restriction for the log
+// channel: the reason, and when the restriction ends (if not forever).
+func restrictionDetails(reason string, until int64) string {
+	if until == 0 {
+		return reason
+	}
+	return fmt.Sprintf("%s\nUntil: %s", reason, time.Unix(until, 0).UTC().Format("2006-01-02 15:04 MST"))
+}
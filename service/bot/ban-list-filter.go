@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/cas"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// banListFilter checks the given user against all ban lists (see
+// banlist.Providers). For the first list with the user and an action in chat
+// settings, the action is performed and it returns true.
+//
+// If check is true, lists that can check single users on demand (like CAS, see
+// banlist.Checker) are queried too: it should be used only when the user is
+// first seen (e.g. on join), as it is slower.
+//
+// Ban list actions are not softened by strikes (see enforceAction). Every
+// match is counted in metrics, even if the chat has no action for that list.
+func (bot *telegramBot) banListFilter(m *tb.Message, user *tb.User, settings chatSettings, check bool) bool {
+	lists := bot.banlists.Lookup
+	if check {
+		lists = bot.banlists.Check
+	}
+	for _, list := range lists(user.ID) {
+		bot.banListMatchTotal.WithLabelValues(list.Name()).Inc()
+		if list.Name() == cas.Name {
+			bot.casDatabaseMatch.Inc()
+		}
+
+		action := settings.BanListAction(list.Name())
+		if action.Action == database.ActionNone {
+			continue
+		}
+		bot.enforceAction(m, user, settings, action, strings.ToUpper(list.Name())+" banned")
+		return true
+	}
+	return false
+}
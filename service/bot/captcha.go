@@ -0,0 +1,347 @@
+package bot
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// defaultCaptchaTimeout is the time to answer a join challenge, when not set in
+// chat settings.
+const defaultCaptchaTimeout = 120 * time.Second
+
+// captchaEmojis are the emojis used in "press the button showing X" challenges,
+// with their (localizable) names.
+var captchaEmojis = []struct {
+	Emoji string
+	Name  string
+}{
+	{"🍎", "apple"},
+	{"🚗", "car"},
+	{"🐶", "dog"},
+	{"⚽️", "ball"},
+	{"🌙", "moon"},
+	{"🎸", "guitar"},
+	{"🔑", "key"},
+	{"☂️", "umbrella"},
+}
+
+// captchaButton is the template of the buttons in challenge messages. The data
+// of each button is the user ID and the option, separated by "|".
+var captchaButton = tb.InlineButton{Unique: "captcha_answer"}
+
+// startCaptcha mutes the given user in the given chat, and sends a join
+// challenge. It returns true if the CAPTCHA took care of the user, false if the
+// CAPTCHA is disabled in chat settings or if it was not possible to create the
+// challenge or to mute the user. If the challenge cannot be sent, the user
+// passes it. A previous challenge for the same user (e.g. the user left and
+// joined again) is replaced.
+//
+// It has no effect on chat admins and on bots (only admins can add bots).
+func (bot *telegramBot) startCaptcha(chat *tb.Chat, user *tb.User, settings chatSettings) bool {
+	if !settings.Captcha.Enabled || user.IsBot || settings.ChatAdmins.IsAdmin(user) {
+		return false
+	}
+	logfields := logrus.Fields{
+		"userid": user.ID,
+		"chatid": chat.ID,
+	}
+
+	lang := user.LanguageCode
+	question, answer, options, err := bot.newCaptchaChallenge(lang)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to create challenge, skipped")
+		return false
+	}
+
+	member, err := bot.telebot.ChatMemberOf(chat, user)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to get member object for user")
+		return false
+	}
+	member.CanSendMessages = false
+	member.CanSendMedia = false
+	member.CanSendPolls = false
+	member.CanSendOther = false
+	member.CanAddPreviews = false
+	if err := bot.telebot.Restrict(chat, member); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to mute user")
+		return false
+	}
+
+	timeout := defaultCaptchaTimeout
+	if settings.Captcha.Timeout > 0 {
+		timeout = time.Duration(settings.Captcha.Timeout) * time.Second
+	}
+
+	bot.removePreviousCaptcha(chat, user)
+
+	var row []tb.InlineButton
+	for _, option := range options {
+		bt := captchaButton
+		bt.Text = option
+		bt.Data = strconv.FormatInt(user.ID, 10) + "|" + option
+		row = append(row, bt)
+	}
+
+	msg := fmt.Sprintf(bot.bundle.T(lang, "Welcome %s! Please answer within %d seconds to show that you are not a bot, otherwise you will be removed.\n\n%s"),
+		strings.TrimSpace(user.FirstName+" "+user.LastName), int(timeout/time.Second), question)
+	challengeMsg, err := bot.telebot.Send(chat, msg, &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{row},
+	})
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to send challenge")
+		bot.passCaptcha(chat, user, settings)
+		return true
+	}
+
+	err = bot.db.AddChallenge(database.Challenge{
+		ChatID:    chat.ID,
+		UserID:    user.ID,
+		MessageID: challengeMsg.ID,
+		Answer:    answer,
+		Deadline:  time.Now().Add(timeout).Unix(),
+	})
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to save challenge")
+		_ = bot.telebot.Delete(challengeMsg)
+		bot.passCaptcha(chat, user, settings)
+		return true
+	}
+	bot.scheduleJob(database.Job{Kind: jobExpireCaptcha, ChatID: chat.ID, UserID: user.ID, MessageID: challengeMsg.ID}, timeout)
+	bot.logger.WithFields(logfields).Info("captcha challenge sent")
+	return true
+}
+
+// removePreviousCaptcha removes the pending challenge of the given user in the
+// given chat, if any, and deletes its message.
+func (bot *telegramBot) removePreviousCaptcha(chat *tb.Chat, user *tb.User) {
+	challenge, err := bot.db.GetChallenge(chat.ID, user.ID)
+	if errors.Is(err, database.ErrChallengeNotFound) {
+		return
+	} else if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to get captcha challenge")
+		return
+	}
+	if err := bot.db.RemoveChallenge(chat.ID, user.ID); err != nil {
+		// Already answered or expired meanwhile.
+		return
+	}
+	if err := bot.telebot.Delete(&tb.Message{ID: challenge.MessageID, Chat: chat}); err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to delete captcha challenge")
+	}
+}
+
+// newCaptchaChallenge returns a random challenge: the question, the correct
+// answer and the options for buttons (in random order, including the answer).
+// It returns an error if the system random source fails.
+func (bot *telegramBot) newCaptchaChallenge(lang string) (string, string, []string, error) {
+	kind, err := randomInt(2)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if kind == 0 {
+		// Press the button showing X.
+		perm, err := randomPerm(len(captchaEmojis))
+		if err != nil {
+			return "", "", nil, err
+		}
+		perm = perm[:4]
+		order, err := randomPerm(len(perm))
+		if err != nil {
+			return "", "", nil, err
+		}
+		answer := captchaEmojis[perm[0]]
+		var options []string
+		for _, i := range order {
+			options = append(options, captchaEmojis[perm[i]].Emoji)
+		}
+		question := fmt.Sprintf(bot.bundle.T(lang, "Press the button showing: %s"), bot.bundle.T(lang, answer.Name))
+		return question, answer.Emoji, options, nil
+	}
+
+	// Simple arithmetic: the wrong options are near the correct answer.
+	a, err := randomInt(9)
+	if err != nil {
+		return "", "", nil, err
+	}
+	b, err := randomInt(9)
+	if err != nil {
+		return "", "", nil, err
+	}
+	a, b = a+1, b+1
+	near, err := randomPerm(8)
+	if err != nil {
+		return "", "", nil, err
+	}
+	answer := a + b
+	values := []int{answer}
+	for _, i := range near {
+		if v := answer - 4 + i; v != answer && v >= 0 && len(values) < 4 {
+			values = append(values, v)
+		}
+	}
+	order, err := randomPerm(len(values))
+	if err != nil {
+		return "", "", nil, err
+	}
+	var options []string
+	for _, i := range order {
+		options = append(options, strconv.Itoa(values[i]))
+	}
+	question := fmt.Sprintf(bot.bundle.T(lang, "How much is %d + %d?"), a, b)
+	return question, strconv.Itoa(answer), options, nil
+}
+
+// onCaptchaAnswer is fired when a user presses a button of a join challenge. A
+// correct answer removes the mute (or starts the probation, if enabled), a
+// wrong answer kicks the user. In both cases the challenge is deleted.
+func (bot *telegramBot) onCaptchaAnswer(ctx tb.Context) error {
+	callback := ctx.Callback()
+	if callback == nil || callback.Message == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Callback, ignored")
+		return nil
+	}
+	lang := callback.Sender.LanguageCode
+	chat := callback.Message.Chat
+
+	parts := strings.SplitN(callback.Data, "|", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		bot.logger.WithError(err).Error("Failed to parse callback data as int64")
+		return nil
+	}
+	if userID != callback.Sender.ID {
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "This challenge is not for you")})
+	}
+
+	challenge, err := bot.db.GetChallenge(chat.ID, userID)
+	if errors.Is(err, database.ErrChallengeNotFound) {
+		return ctx.Respond()
+	} else if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to get captcha challenge")
+		return ctx.Respond()
+	}
+
+	// Only the first caller that removes the challenge acts on it.
+	if err := bot.db.RemoveChallenge(chat.ID, userID); err != nil {
+		if !errors.Is(err, database.ErrChallengeNotFound) {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to remove captcha challenge")
+		}
+		return ctx.Respond()
+	}
+	if err := bot.telebot.Delete(callback.Message); err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to delete captcha challenge")
+	}
+
+	settings, err := bot.getChatSettings(chat)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Cannot get chat settings")
+		return ctx.Respond()
+	}
+
+	if parts[1] != challenge.Answer {
+		_ = ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "Wrong answer")})
+		bot.kickUser(chat, callback.Sender, settings, "CAPTCHA: wrong answer")
+		return nil
+	}
+
+	_ = ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "Welcome!")})
+	bot.passCaptcha(chat, callback.Sender, settings)
+	return nil
+}
+
+// passCaptcha removes the mute on the given user after a correct answer. If the
+// probation is enabled, the user starts the probation instead.
+func (bot *telegramBot) passCaptcha(chat *tb.Chat, user *tb.User, settings chatSettings) {
+	logfields := logrus.Fields{
+		"userid": user.ID,
+		"chatid": chat.ID,
+	}
+	bot.logger.WithFields(logfields).Info("captcha passed or skipped")
+
+	if settings.Probation.Enabled {
+		bot.startProbation(chat, user, settings)
+		return
+	}
+
+	member, err := bot.telebot.ChatMemberOf(chat, user)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to get member object for user")
+		return
+	}
+	member.Rights = tb.NoRestrictions()
+	member.RestrictedUntil = tb.Forever()
+	if err := bot.telebot.Restrict(chat, member); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("captcha: failed to unmute user")
+	}
+}
+
+// expireCaptcha kicks the given user if the challenge in the given message is
+// still pending, and deletes the challenge. It is executed by the scheduler at
+// the challenge deadline: if the user answered, or joined again and got a new
+// challenge meanwhile, it does nothing.
+func (bot *telegramBot) expireCaptcha(chat *tb.Chat, user *tb.User, messageID int) error {
+	challenge, err := bot.db.GetChallenge(chat.ID, user.ID)
+	if errors.Is(err, database.ErrChallengeNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if challenge.MessageID != messageID {
+		return nil
+	}
+
+	settings, err := bot.getChatSettings(chat)
+	if err != nil {
+		return err
+	}
+	// The challenge is removed only after the kick, so a failed kick is
+	// retried by the scheduler.
+	if err := bot.kickUser(chat, user, settings, "CAPTCHA: timeout"); err != nil {
+		return err
+	}
+	if err := bot.db.RemoveChallenge(chat.ID, user.ID); err != nil && !errors.Is(err, database.ErrChallengeNotFound) {
+		return err
+	}
+	if err := bot.telebot.Delete(&tb.Message{ID: messageID, Chat: chat}); err != nil && !errors.Is(err, tb.ErrNotFoundToDelete) {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to delete captcha challenge")
+	}
+	return nil
+}
+
+// randomInt returns a uniform random integer in [0, n), or an error if the
+// system random source fails.
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("on reading random number: %w", err)
+	}
+	return int(v.Int64()), nil
+}
+
+// randomPerm returns a random permutation of the integers in [0, n), or an
+// error if the system random source fails.
+func randomPerm(n int) ([]int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		perm[i] = perm[j]
+		perm[j] = i
+	}
+	return perm, nil
+}
@@ -35,16 +35,7 @@ func (bot *telegramBot) getChatSettings(chat *tb.Chat) (chatSettings, error) {
 				Duration: 0,
 				Delay:    0,
 			},
-			OnMessageChinese: database.BotAction{
-				Action:   database.ActionNone,
-				Duration: 0,
-				Delay:    0,
-			},
-			OnMessageArabic: database.BotAction{
-				Action:   database.ActionNone,
-				Duration: 0,
-				Delay:    0,
-			},
+			Detectors: map[string]database.DetectorSettings{},
 			OnBlacklistCAS: database.BotAction{
 				Action:   database.ActionNone,
 				Duration: 0,
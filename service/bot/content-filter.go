@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// defaultRecentWindow is the time after joining in which a member is recent,
+// when not set in chat settings.
+const defaultRecentWindow = 24 * time.Hour
+
+// contentTypes are the content types of the content policy, with their
+// (localizable) names, in the order shown in the settings panel.
+var contentTypes = []struct {
+	Key  string
+	Name string
+}{
+	{database.ContentForwardChannel, "Forwards from channels"},
+	{database.ContentForwardUser, "Forwards from users"},
+	{database.ContentViaBot, "Inline bots"},
+	{database.ContentContact, "Contacts"},
+	{database.ContentLocation, "Locations"},
+	{database.ContentPoll, "Polls"},
+	{database.ContentDice, "Dice and games"},
+	{database.ContentVoiceNote, "Voice and video notes"},
+}
+
+// messageContentTypes returns the content types of the given message, except
+// ContentForwardChannel (see contentFilter).
+func messageContentTypes(m *tb.Message) []string {
+	var types []string
+	if (m.OriginalSender != nil && m.OriginalSender.ID != m.Sender.ID) || m.OriginalSenderName != "" {
+		types = append(types, database.ContentForwardUser)
+	}
+	if m.Via != nil {
+		types = append(types, database.ContentViaBot)
+	}
+	if m.Contact != nil {
+		types = append(types, database.ContentContact)
+	}
+	if m.Location != nil || m.Venue != nil {
+		types = append(types, database.ContentLocation)
+	}
+	if m.Poll != nil {
+		types = append(types, database.ContentPoll)
+	}
+	if m.Dice != nil || m.Game != nil {
+		types = append(types, database.ContentDice)
+	}
+	if m.Voice != nil || m.VideoNote != nil {
+		types = append(types, database.ContentVoiceNote)
+	}
+	return types
+}
+
+// contentFilter checks the given message against the content policy in chat
+// settings. It returns true if the message breaks a rule and an action has
+// been performed.
+//
+// Chat admins are not checked.
+func (bot *telegramBot) contentFilter(m *tb.Message, settings chatSettings) bool {
+	rules := settings.Content.Rules
+	if len(rules) == 0 || settings.Content.Action.Action == database.ActionNone || settings.ChatAdmins.IsAdmin(m.Sender) {
+		return false
+	}
+
+	types := messageContentTypes(m)
+	if m.OriginalChat != nil && rules[database.ContentForwardChannel] != database.ContentAllow {
+		inNetwork, err := bot.db.IsChatInNetwork(m.OriginalChat.ID)
+		if err != nil {
+			bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to check if the forward origin is in the network")
+		} else if !inNetwork {
+			types = append(types, database.ContentForwardChannel)
+		}
+	}
+
+	var recent *bool
+	for _, t := range types {
+		switch rules[t] {
+		case database.ContentBlock:
+		case database.ContentBlockRecent:
+			if recent == nil {
+				isRecent := bot.isRecentMember(m.Chat, m.Sender, settings)
+				recent = &isRecent
+			}
+			if !*recent {
+				continue
+			}
+		default:
+			continue
+		}
+		bot.performAction(m, m.Sender, settings, settings.Content.Action, "Content policy: "+t+" not allowed")
+		return true
+	}
+	return false
+}
+
+// isRecentMember returns true if the given user joined the given chat within
+// the recent window in chat settings.
+func (bot *telegramBot) isRecentMember(chat *tb.Chat, user *tb.User, settings chatSettings) bool {
+	joined, err := bot.db.MemberJoinedAt(chat.ID, user.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to get member join time")
+		return false
+	} else if joined.IsZero() {
+		return false
+	}
+
+	window := defaultRecentWindow
+	if settings.Content.RecentWindow > 0 {
+		window = time.Duration(settings.Content.RecentWindow) * time.Second
+	}
+	return time.Since(joined) < window
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// duplicateFilter records the fingerprint of the given message in the network
+// store, and checks whether the sender posted the same content in more than
+// duplicateChats different chats in the last duplicateWindow. It returns true
+// if an action has been performed on the given message.
+//
+// When the limit is exceeded for the first time, the OnDuplicate action of each
+// chat is performed on every recorded message (and the user is g-lined if
+// duplicateGLine is set). Later duplicates are acted on as they arrive.
+//
+// Messages are recorded regardless of the chat settings, as no single chat can
+// see this pattern. Edited messages, messages from chat admins, and messages
+// that normal users repeat across chats (see isDuplicateCandidate) are not
+// recorded.
+func (bot *telegramBot) duplicateFilter(m *tb.Message, settings chatSettings) bool {
+	if bot.duplicateChats == 0 || m.LastEdit != 0 || settings.ChatAdmins.IsAdmin(m.Sender) || !isDuplicateCandidate(m) {
+		return false
+	}
+	fingerprint, ok := messageFingerprint(m)
+	if !ok {
+		return false
+	}
+
+	logger := bot.logger.WithFields(logrus.Fields{
+		"chatid": m.Chat.ID,
+		"userid": m.Sender.ID,
+	})
+
+	messages, err := bot.db.AddNetworkFingerprint(m.Sender.ID, fingerprint, m.Chat.ID, m.ID, bot.duplicateWindow)
+	if err != nil {
+		logger.WithError(err).Error("Failed to record message for duplicate detection")
+		return false
+	}
+
+	chats := make(map[int64][]int)
+	for _, msg := range messages {
+		chats[msg.ChatID] = append(chats[msg.ChatID], msg.MessageID)
+	}
+	if uint(len(chats)) <= bot.duplicateChats {
+		return false
+	}
+
+	isGlobalAdmin, err := bot.db.IsBotAdmin(m.Sender.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check if the user is a global admin")
+		return false
+	} else if isGlobalAdmin {
+		return false
+	}
+
+	reason := fmt.Sprintf("Duplicate: same message in %d chats in %s", len(chats), bot.duplicateWindow)
+
+	// The limit was already exceeded by a previous message: act only on this one.
+	if uint(len(chats)) > bot.duplicateChats+1 || len(chats[m.Chat.ID]) > 1 {
+		bot.performAction(m, m.Sender, settings, settings.OnDuplicate, reason)
+		return settings.OnDuplicate.Action != database.ActionNone
+	}
+
+	logger.WithField("chats", len(chats)).Info("Network duplicate detected")
+	if bot.duplicateGLine {
+		if err := bot.db.SetUserBanned(m.Sender.ID); err != nil {
+			logger.WithError(err).Error("Failed to add g-line")
+		} else {
+			logger.Info("g-line user")
+		}
+	}
+
+	acted := false
+	for chatID, messageIDs := range chats {
+		chatsettings := settings
+		if chatID != m.Chat.ID {
+			chatsettings, err = bot.getChatSettings(&tb.Chat{ID: chatID})
+			if err != nil {
+				logger.WithError(err).WithField("otherchatid", chatID).Error("Failed to get chat settings")
+				continue
+			}
+			if !chatsettings.BotEnabled {
+				continue
+			}
+		}
+
+		action := chatsettings.OnDuplicate
+		if bot.duplicateGLine {
+			// The g-line bans the user everywhere, even where OnDuplicate
+			// is not set.
+			action = database.BotAction{Action: database.ActionBan}
+		}
+		if action.Action == database.ActionNone {
+			continue
+		}
+		acted = acted || chatID == m.Chat.ID
+
+		// The action on the user is performed once per chat, other messages
+		// are only deleted.
+		for i, messageID := range messageIDs {
+			msg := &tb.Message{ID: messageID, Chat: &tb.Chat{ID: chatID}, Sender: m.Sender}
+			if chatID == m.Chat.ID && messageID == m.ID {
+				msg = m
+			}
+			if i == 0 {
+				if bot.duplicateGLine {
+					bot.enforceAction(msg, m.Sender, chatsettings, action, reason)
+				} else {
+					bot.performAction(msg, m.Sender, chatsettings, action, reason)
+				}
+			} else {
+				bot.deleteMessage(msg, chatsettings, reason)
+			}
+		}
+	}
+	return acted
+}
+
+// minDuplicateLength is the minimum length (in characters, after normalization)
+// of the text and caption of a message for the network duplicate detection.
+const minDuplicateLength = 30
+
+// isDuplicateCandidate returns true if the given message can be a network
+// duplicate. Stickers and short messages (like greetings or thanks) are
+// excluded, as normal users send the same ones in many chats.
+func isDuplicateCandidate(m *tb.Message) bool {
+	if m.Sticker != nil {
+		return false
+	}
+	text := strings.TrimSpace(antispam.Normalize(m.Text)) + strings.TrimSpace(antispam.Normalize(m.Caption))
+	return utf8.RuneCountInString(text) >= minDuplicateLength
+}
@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+func TestIsDuplicateCandidate(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *tb.Message
+		want bool
+	}{
+		{"greeting", &tb.Message{Text: "grazie"}, false},
+		{"padded greeting", &tb.Message{Text: "   Buongiorno a tutti!   "}, false},
+		{"sticker", &tb.Message{Sticker: &tb.Sticker{File: tb.File{UniqueID: "AgADBQAD"}}}, false},
+		{"photo without caption", &tb.Message{Photo: &tb.Photo{File: tb.File{UniqueID: "AgADBQAD"}}}, false},
+		{"long text", &tb.Message{Text: "Guadagna 500 euro al giorno con le crypto, scrivimi in privato"}, true},
+		{"long caption", &tb.Message{Caption: "Guadagna 500 euro al giorno con le crypto, scrivimi in privato"}, true},
+		{"multibyte text", &tb.Message{Text: strings.Repeat("è", minDuplicateLength-1)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateCandidate(tt.m); got != tt.want {
+				t.Errorf("isDuplicateCandidate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateFilterShortMessage(t *testing.T) {
+	// The database is nil: short messages must be skipped before recording.
+	bot := &telegramBot{duplicateChats: 1}
+	m := &tb.Message{
+		ID:     1,
+		Text:   "grazie",
+		Chat:   &tb.Chat{ID: -100},
+		Sender: &tb.User{ID: 42},
+	}
+	for i := 0; i < 5; i++ {
+		if bot.duplicateFilter(m, chatSettings{}) {
+			t.Fatalf("duplicateFilter #%d = true, want false", i)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// floodFilter counts the given message in the sliding windows of its sender,
+// and performs the flood action if the sender exceeds the chat limits. It
+// returns true if the action has been performed.
+//
+// Raw volume and identical-content repeats are counted separately. Edited
+// messages and messages from chat admins are not counted.
+func (bot *telegramBot) floodFilter(m *tb.Message, settings chatSettings) bool {
+	if settings.OnFlood.Action == database.ActionNone || settings.Flood.Window == 0 {
+		return false
+	}
+	if m.LastEdit != 0 || settings.ChatAdmins.IsAdmin(m.Sender) {
+		return false
+	}
+
+	logger := bot.logger.WithFields(logrus.Fields{
+		"chatid": m.Chat.ID,
+		"userid": m.Sender.ID,
+	})
+	window := time.Duration(settings.Flood.Window) * time.Second
+
+	if settings.Flood.Messages > 0 {
+		count, err := bot.db.CountFloodMessage(m.Chat.ID, m.Sender.ID, m.ID, window)
+		if err != nil {
+			logger.WithError(err).Error("Failed to count messages for flood detection")
+			return false
+		}
+		if count > int64(settings.Flood.Messages) {
+			reason := fmt.Sprintf("Flood: %d messages in %d seconds", count, settings.Flood.Window)
+			bot.performAction(m, m.Sender, settings, settings.OnFlood, reason)
+			return true
+		}
+	}
+
+	if fingerprint, ok := messageFingerprint(m); ok && settings.Flood.Repeats > 0 {
+		count, err := bot.db.CountFloodRepeat(m.Chat.ID, m.Sender.ID, fingerprint, m.ID, window)
+		if err != nil {
+			logger.WithError(err).Error("Failed to count repeated messages for flood detection")
+			return false
+		}
+		if count > int64(settings.Flood.Repeats) {
+			reason := fmt.Sprintf("Flood: %d identical messages in %d seconds", count, settings.Flood.Window)
+			bot.performAction(m, m.Sender, settings, settings.OnFlood, reason)
+			return true
+		}
+	}
+	return false
+}
+
+// messageFingerprint returns a fingerprint of the message content: the SHA1 of
+// normalized text and caption (see antispam.Normalize), and the unique ID of any
+// media or sticker. Messages with the same content have the same fingerprint.
+// It returns false if the message has no content to compare (e.g. locations,
+// polls or dice).
+func messageFingerprint(m *tb.Message) (string, bool) {
+	parts := []string{
+		strings.TrimSpace(antispam.Normalize(m.Text)),
+		strings.TrimSpace(antispam.Normalize(m.Caption)),
+	}
+	if media := m.Media(); media != nil && media.MediaFile() != nil {
+		parts = append(parts, media.MediaFile().UniqueID)
+	}
+	if m.Sticker != nil {
+		parts = append(parts, m.Sticker.UniqueID)
+	}
+	if strings.Join(parts, "") == "" {
+		return "", false
+	}
+	return sha1string(strings.Join(parts, "\x00")), true
+}
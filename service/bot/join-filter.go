@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// joinHeuristic is a check on the profile of a joining user.
+type joinHeuristic struct {
+	// Reason is the reason recorded in the log
+	Reason string
+
+	// Action returns the action for this heuristic in chat settings
+	Action func(settings chatSettings) database.BotAction
+
+	// Match returns true if the given profile matches the heuristic. The name
+	// is normalized (see antispam.Normalize), the bio is fetched on demand
+	Match func(user *tb.User, name string, bio func() string) bool
+}
+
+// joinHeuristics are the checks done by joinFilter, in order.
+var joinHeuristics = []joinHeuristic{
+	{
+		Reason: "Chinese name on join",
+		Action: func(settings chatSettings) database.BotAction { return settings.OnJoinChinese },
+		Match: func(user *tb.User, name string, bio func() string) bool {
+			detector := antispam.NewChineseDetector()
+			score, _ := detector.Detect(name)
+			return score > detector.Threshold()
+		},
+	},
+	{
+		Reason: "Arabic name on join",
+		Action: func(settings chatSettings) database.BotAction { return settings.OnJoinArabic },
+		Match: func(user *tb.User, name string, bio func() string) bool {
+			detector := antispam.NewArabicDetector()
+			score, _ := detector.Detect(name)
+			return score > detector.Threshold()
+		},
+	},
+	{
+		Reason: "Links or mentions in name on join",
+		Action: func(settings chatSettings) database.BotAction { return settings.OnJoinNameLink },
+		Match: func(user *tb.User, name string, bio func() string) bool {
+			return antispam.HasLinkOrMention(name)
+		},
+	},
+	{
+		Reason: "Name without letters on join",
+		Action: func(settings chatSettings) database.BotAction { return settings.OnJoinSymbolName },
+		Match: func(user *tb.User, name string, bio func() string) bool {
+			return antispam.IsSymbolsOnly(name)
+		},
+	},
+	{
+		Reason: "No username on join",
+		Action: func(settings chatSettings) database.BotAction { return settings.OnJoinNoUsername },
+		Match: func(user *tb.User, name string, bio func() string) bool {
+			return user.Username == ""
+		},
+	},
+	{
+		Reason: "Links or mentions in bio on join",
+		Action: func(settings chatSettings) database.BotAction { return settings.OnJoinBioLink },
+		Match: func(user *tb.User, name string, bio func() string) bool {
+			return antispam.HasLinkOrMention(antispam.Normalize(bio()))
+		},
+	},
+}
+
+// joinFilter checks the profile of the given joining user against the join
+// heuristics. For the first matching heuristic with an action in chat settings,
+// the action is performed on the join message and it returns true.
+//
+// Join actions are not softened by strikes (see enforceAction). The bio is
+// fetched only if needed. Chat admins and bots are not checked.
+func (bot *telegramBot) joinFilter(m *tb.Message, user *tb.User, settings chatSettings) bool {
+	if user.IsBot || settings.ChatAdmins.IsAdmin(user) {
+		return false
+	}
+
+	name := antispam.Normalize(strings.TrimSpace(user.FirstName + " " + user.LastName))
+	var bio *string
+	fetchBio := func() string {
+		if bio == nil {
+			chat, err := bot.telebot.ChatByID(user.ID)
+			if err != nil {
+				bot.logger.WithError(err).WithField("userid", user.ID).Debug("Failed to get user bio")
+				chat = &tb.Chat{}
+			}
+			bio = &chat.Bio
+		}
+		return *bio
+	}
+
+	for _, h := range joinHeuristics {
+		action := h.Action(settings)
+		if action.Action == database.ActionNone || !h.Match(user, name, fetchBio) {
+			continue
+		}
+		bot.enforceAction(m, user, settings, action, h.Reason)
+		return true
+	}
+	return false
+}
@@ -26,6 +26,13 @@ func (bot *telegramBot) ListenAndServe() error {
 	bot.simpleHandler(tb.OnText, bot.onAnyMessage)
 	bot.simpleHandler(tb.OnSticker, bot.onAnyMessage)
 	bot.simpleHandler(tb.OnAnimation, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnVideoNote, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnContact, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnLocation, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnVenue, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnPoll, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnDice, bot.onAnyMessage)
+	bot.simpleHandler(tb.OnGame, bot.onAnyMessage)
 	bot.simpleHandler(tb.OnUserJoined, bot.onUserJoined)
 	bot.simpleHandler(tb.OnAddedToGroup, bot.onAddedToGroup)
 	bot.simpleHandler(tb.OnUserLeft, bot.onUserLeft)
@@ -36,6 +43,7 @@ func (bot *telegramBot) ListenAndServe() error {
 	bot.simpleHandler("/groups", bot.onGroups)
 	bot.simpleHandler("/gruppi", bot.onGroups)
 	bot.simpleHandler("/dont", bot.onDont)
+	bot.simpleHandler("/report", bot.onReport)
 
 	// Chat-admin commands
 	bot.chatAdminHandler("/impostazioni", bot.onSettings)
@@ -43,6 +51,15 @@ func (bot *telegramBot) ListenAndServe() error {
 	bot.chatAdminHandler("/terminate", bot.onTerminate)
 	bot.chatAdminHandler("/reload", bot.onReloadGroup)
 	bot.chatAdminHandler("/sigterm", bot.onSigTerm)
+	bot.chatAdminHandler("/keywords", bot.onKeywords)
+	bot.chatAdminHandler("/spam", bot.onSpam)
+	bot.chatAdminHandler("/ham", bot.onHam)
+	bot.chatAdminHandler("/warn", bot.onWarn)
+	bot.chatAdminHandler("/unwarn", bot.onUnwarn)
+	bot.chatAdminHandler("/warns", bot.onWarns)
+	for _, cmd := range moderationCommands {
+		bot.chatAdminHandler("/"+cmd.Name, bot.onModerationCommand(cmd))
+	}
 
 	// Global-administrative commands
 	bot.globalAdminHandler("/sighup", bot.onSigHup)
@@ -50,6 +67,20 @@ func (bot *telegramBot) ListenAndServe() error {
 	bot.globalAdminHandler("/updatewww", bot.onGlobalUpdateWWW)
 	bot.globalAdminHandler("/gline", bot.onGLine)
 	bot.globalAdminHandler("/remove_gline", bot.onRemoveGLine)
+	bot.globalAdminHandler("/casstatus", bot.onCASStatus)
+
+	// Join CAPTCHA answers (from any user)
+	bot.telebot.Handle(&captchaButton, bot.onCaptchaAnswer)
+
+	// Report notification buttons (admins are checked by the handler)
+	bot.telebot.Handle(&reportDeleteButton, bot.onReportAction)
+	bot.telebot.Handle(&reportMuteButton, bot.onReportAction)
+	bot.telebot.Handle(&reportBanButton, bot.onReportAction)
+	bot.telebot.Handle(&reportGLineButton, bot.onReportAction)
+	bot.telebot.Handle(&reportDismissButton, bot.onReportAction)
+
+	// Lockdown alert buttons (admins are checked by the handler)
+	bot.telebot.Handle(&endLockdownButton, bot.onEndLockdown)
 
 	// Utilities
 	bot.simpleHandler("/id", func(ctx tb.Context, settings chatSettings) {
@@ -78,6 +109,25 @@ func (bot *telegramBot) ListenAndServe() error {
 		}
 	}()
 
+	// Quiet hours. Boundaries crossed while the bot was down are applied at
+	// startup, then each boundary is a scheduled job
+	go func() {
+		if err := bot.updateAllQuietHours(); err != nil {
+			bot.logger.WithError(err).Error("error updating quiet hours")
+		}
+	}()
+
+	// Scheduled jobs. Jobs due while the bot was down are executed at startup
+	go func() {
+		t := time.NewTicker(1 * time.Second)
+		for {
+			if err := bot.runDueJobs(); err != nil {
+				bot.logger.WithError(err).Error("error running scheduled jobs")
+			}
+			<-t.C
+		}
+	}()
+
 	// Let's go!
 	bot.telebot.Start()
 	return nil
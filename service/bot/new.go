@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/banlist"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/cas"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/i18n"
@@ -28,6 +30,9 @@ type Options struct {
 	// CAS is the CAS database instance
 	CAS cas.CAS
 
+	// BanLists are other ban lists, checked after CAS
+	BanLists []banlist.BanListProvider
+
 	// Bundle is the Bundle instance to get localized strings. Required.
 	Bundle *i18n.Bundle
 
@@ -42,6 +47,16 @@ type Options struct {
 
 	// LongPollerTimeout is the timeout for long polling. Default: 10s
 	LongPollerTimeout time.Duration
+
+	// DuplicateChats is the number of chats above which a message sent by the same user in the network is a
+	// duplicate. Zero disables the network duplicate detection
+	DuplicateChats uint
+
+	// DuplicateWindow is the time window for the network duplicate detection. Default: 10m
+	DuplicateWindow time.Duration
+
+	// DuplicateGLine enables the automatic G-Line of users sending network duplicates
+	DuplicateGLine bool
 }
 
 // New returns a new TelegramBot compliant instance.
@@ -65,6 +80,9 @@ func New(opts Options) (TelegramBot, error) {
 	if opts.LongPollerTimeout == 0 {
 		opts.LongPollerTimeout = 10 * time.Second
 	}
+	if opts.DuplicateWindow == 0 {
+		opts.DuplicateWindow = 10 * time.Minute
+	}
 
 	// Initialize bot library
 	telebot, err := tb.NewBot(tb.Settings{
@@ -79,14 +97,39 @@ func New(opts Options) (TelegramBot, error) {
 		logger:              opts.Logger,
 		db:                  opts.Database,
 		cas:                 opts.CAS,
+		classifier:          antispam.NewBayesClassifier(opts.Database),
 		bundle:              opts.Bundle,
 		gitTemporaryDir:     opts.GitTemporaryDir,
 		gitSSHKey:           opts.GitSSHKeyFile,
 		gitSSHKeyPassphrase: opts.GitSSHKeyPassphrase,
+		duplicateChats:      opts.DuplicateChats,
+		duplicateWindow:     opts.DuplicateWindow,
+		duplicateGLine:      opts.DuplicateGLine,
 		telebot:             telebot,
 	}
 
+	if opts.CAS != nil {
+		t.banlists = append(t.banlists, opts.CAS)
+	}
+	t.banlists = append(t.banlists, opts.BanLists...)
+
+	t.detectors = antispam.NewRegistry(
+		antispam.NewChineseDetector(),
+		antispam.NewArabicDetector(),
+		antispam.NewConfusableDetector(),
+		antispam.NewMentionDetector(),
+		antispam.NewEmojiDetector(),
+		antispam.NewCapsDetector(),
+		antispam.NewZalgoDetector(),
+		antispam.NewInvisibleDetector(),
+		antispam.NewBayesDetector(t.classifier, func(err error) {
+			t.logger.WithError(err).Error("Failed to score message with the spam classifier")
+		}),
+	)
+
 	t.statemgmt = cache.New(60*time.Minute, 60*time.Minute)
+	t.keywordDetectors = cache.New(keywordDetectorTTL, 2*keywordDetectorTTL)
+	t.userLanguages = cache.New(userLanguageTTL, 2*userLanguageTTL)
 
 	// Initialize metrics
 	t.promreg = prometheus.NewRegistry()
@@ -127,19 +170,98 @@ func New(opts Options) (TelegramBot, error) {
 	}, []string{"userid", "username"})
 
 	// CAS database
-	/*t.casDatabaseDownloadTime = promauto.With(t.promreg).NewGauge(prometheus.GaugeOpts{
-		Name: "cas_database_download_time",
-		Help: "The time elapsed for downloading the CAS database",
-	})
-	t.casDatabaseSize = promauto.With(t.promreg).NewGauge(prometheus.GaugeOpts{
-		Name: "cas_database_size",
-		Help: "The number of items in the CAS database",
-	})*/
+	if t.cas != nil {
+		casStats := func(fn func(stats banlist.Stats) float64) func() float64 {
+			return func() float64 {
+				return fn(t.cas.Stats())
+			}
+		}
+		_ = promauto.With(t.promreg).NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cas_database_download_time",
+			Help: "The time elapsed for downloading the CAS database (in milliseconds)",
+		}, casStats(func(stats banlist.Stats) float64 {
+			return float64(stats.Duration / time.Millisecond)
+		}))
+		_ = promauto.With(t.promreg).NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cas_database_size",
+			Help: "The number of items in the CAS database",
+		}, casStats(func(stats banlist.Stats) float64 {
+			return float64(stats.Items)
+		}))
+		_ = promauto.With(t.promreg).NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cas_database_last_update_timestamp_seconds",
+			Help: "The time of the loaded CAS database (unix time, 0 if not loaded)",
+		}, casStats(func(stats banlist.Stats) float64 {
+			if stats.Updated.IsZero() {
+				return 0
+			}
+			return float64(stats.Updated.Unix())
+		}))
+		_ = promauto.With(t.promreg).NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cas_database_consecutive_failures",
+			Help: "The number of failed CAS database updates since the last successful one",
+		}, casStats(func(stats banlist.Stats) float64 {
+			return float64(stats.ConsecutiveFailures)
+		}))
+		for reason, fn := range map[string]func(stats banlist.Stats) float64{
+			"timeout":    func(stats banlist.Stats) float64 { return float64(stats.Timeouts) },
+			"cloudflare": func(stats banlist.Stats) float64 { return float64(stats.CloudflareLimited) },
+			"other": func(stats banlist.Stats) float64 {
+				return float64(stats.Failures - stats.Timeouts - stats.CloudflareLimited)
+			},
+		} {
+			_ = promauto.With(t.promreg).NewCounterFunc(prometheus.CounterOpts{
+				Name:        "cas_database_update_failures_total",
+				Help:        "The number of failed CAS database updates",
+				ConstLabels: prometheus.Labels{"reason": reason},
+			}, casStats(fn))
+		}
+	}
 	t.casDatabaseMatch = promauto.With(t.promreg).NewCounter(prometheus.CounterOpts{
 		Name: "cas_database_match",
 		Help: "The number of users in the CAS database matched",
 	})
 
+	// Ban lists
+	t.banListMatchTotal = promauto.With(t.promreg).NewCounterVec(prometheus.CounterOpts{
+		Name: "banlist_match_total",
+		Help: "The number of users matched per ban list",
+	}, []string{"banlist"})
+
+	// Antispam
+	t.keywordMatchTotal = promauto.With(t.promreg).NewCounterVec(prometheus.CounterOpts{
+		Name: "antispam_keyword_match_total",
+		Help: "The number of messages matched by keyword blocklist rules, by position of the rule in the chat blocklist",
+	}, []string{"rule"})
+
+	// Scheduler
+	_ = promauto.With(t.promreg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "scheduler_queue_depth",
+		Help: "The number of scheduled jobs in the queue",
+	}, func() float64 {
+		depth, _, err := t.db.JobQueueStats(time.Now())
+		if err != nil {
+			t.logger.WithError(err).Error("can't get job queue stats")
+			return 0
+		}
+		return float64(depth)
+	})
+	_ = promauto.With(t.promreg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "scheduler_lag_seconds",
+		Help: "How late the oldest due job in the queue is",
+	}, func() float64 {
+		_, lag, err := t.db.JobQueueStats(time.Now())
+		if err != nil {
+			t.logger.WithError(err).Error("can't get job queue stats")
+			return 0
+		}
+		return lag.Seconds()
+	})
+	t.schedulerJobsTotal = promauto.With(t.promreg).NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_jobs_total",
+		Help: "The number of executed scheduled jobs",
+	}, []string{"kind", "result"})
+
 	// Bot commands
 	t.botCommandsRequestsTotal = promauto.With(t.promreg).NewCounterVec(prometheus.CounterOpts{
 		Name: "bot_commands_requests_total",
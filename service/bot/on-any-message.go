@@ -77,19 +77,46 @@ func (bot *telegramBot) onAnyMessage(ctx tb.Context, settings chatSettings) {
 		_ = stateAddBotAdmin(bot, ctx, state)
 		return
 	}
+	if state.AddLinkAllow || state.AddLinkDeny {
+		bot.stateAddLinkDomains(ctx, state)
+		return
+	}
+	if state.AddKeyword || state.AddKeywordRegexp {
+		bot.stateAddKeywords(ctx, state)
+		return
+	}
 
 	if !m.Private() { // On groups check message against antispam system.
 		// G-Line check
 		if banned, err := bot.db.IsUserBanned(m.Sender.ID); err == nil && banned {
-			bot.banUser(m.Chat, m.Sender, settings, "user g-lined")
+			bot.banUser(m.Chat, m.Sender, settings, 0, "user g-lined")
 			bot.deleteMessage(m, settings, "user g-lined")
 			return
 		}
 
-		// CAS ban check.
-		if bot.cas != nil && bot.cas.IsBanned(m.Sender.ID) {
-			bot.casDatabaseMatch.Inc()
-			bot.performAction(m, m.Sender, settings, settings.OnBlacklistCAS, "CAS banned")
+		// Ban lists check (CAS and others). On the first message of the user
+		// in the chat, lists are checked on demand too, as the user may be
+		// banned after the last update of the lists.
+		first, err := bot.db.SetMemberSeen(m.Chat.ID, m.Sender.ID)
+		if err != nil {
+			bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Warn("Failed to record the first message of the user")
+		}
+		if bot.banListFilter(m, m.Sender, settings, first) {
+			return
+		}
+
+		// Flood check.
+		if bot.floodFilter(m, settings) {
+			return
+		}
+
+		// Network duplicate check.
+		if bot.duplicateFilter(m, settings) {
+			return
+		}
+
+		// Content types check (forwards, polls, etc.).
+		if bot.contentFilter(m, settings) {
 			return
 		}
 
@@ -111,8 +138,18 @@ func (bot *telegramBot) onAnyMessage(ctx tb.Context, settings chatSettings) {
 		if m.Video != nil {
 			textvalues = append(textvalues, m.Video.Caption)
 		}
+		// Links in text_link entities are not part of the text.
+		textvalues = append(textvalues, entityURLs(m.Entities)...)
+		textvalues = append(textvalues, entityURLs(m.CaptionEntities)...)
+
+		// New members in probation cannot send links and forwards.
+		if bot.probationFilter(m, settings, textvalues) {
+			return
+		}
 
-		bot.spamFilter(m, settings, textvalues)
+		if !bot.spamFilter(m, settings, textvalues) {
+			bot.countProbationMessage(m, settings)
+		}
 	}
 }
 
@@ -190,3 +227,25 @@ func stateAddBotAdmin(bot *telegramBot, ctx tb.Context, state State) error {
 	_, _ = bot.telebot.Send(m.Chat, msg, options)
 	return nil
 }
+
+// entityURLs returns the URLs of all text_link entities in the given list.
+func entityURLs(entities tb.Entities) []string {
+	var urls []string
+	for _, e := range entities {
+		if e.Type == tb.EntityTextLink && e.URL != "" {
+			urls = append(urls, e.URL)
+		}
+	}
+	return urls
+}
+
+// countEntities returns the number of entities of the given type.
+func countEntities(entities tb.Entities, entityType tb.EntityType) int {
+	n := 0
+	for _, e := range entities {
+		if e.Type == entityType {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/banlist"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// onCASStatus sends the statistics of the CAS database updates on /casstatus
+// command, to find out when the database is stale.
+func (bot *telegramBot) onCASStatus(ctx tb.Context, settings chatSettings) {
+	lang := ctx.Sender().LanguageCode
+
+	if bot.cas == nil {
+		_ = ctx.Send(bot.bundle.T(lang, "CAS database not configured"))
+		return
+	}
+	stats := bot.cas.Stats()
+
+	buf := strings.Builder{}
+	buf.WriteString(bot.bundle.T(lang, "CAS database status\n\n"))
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Items: %d\n"), stats.Items))
+	if stats.Updated.IsZero() {
+		buf.WriteString(bot.bundle.T(lang, "Updated: never\n"))
+	} else {
+		buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Updated: %s (%s ago)\n"), stats.Updated.UTC().Format(time.RFC3339), time.Since(stats.Updated).Round(time.Second)))
+	}
+	if stats.Duration > 0 {
+		buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Last download time: %s\n"), stats.Duration.Round(time.Millisecond)))
+	}
+	if !stats.LastAttempt.IsZero() {
+		buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Last attempt: %s\n"), stats.LastAttempt.UTC().Format(time.RFC3339)))
+	}
+
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Consecutive failures: %d\n"), stats.ConsecutiveFailures))
+	if stats.LastError != nil {
+		buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Last error: %s\n"), bot.prettyBanListError(stats.LastError, lang)))
+	}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Total failures: %d (timeouts: %d, CloudFlare limits: %d)\n"), stats.Failures, stats.Timeouts, stats.CloudflareLimited))
+
+	_ = ctx.Send(buf.String())
+}
+
+// prettyBanListError returns an human-friendly description of the given ban
+// list update error.
+func (bot *telegramBot) prettyBanListError(err error, lang string) string {
+	switch {
+	case errors.Is(err, banlist.ErrTimeout):
+		return bot.bundle.T(lang, "timeout")
+	case errors.Is(err, banlist.ErrCloudflareLimited):
+		return bot.bundle.T(lang, "limited by CloudFlare")
+	case errors.Is(err, banlist.ErrTruncated):
+		return bot.bundle.T(lang, "truncated download")
+	case errors.Is(err, banlist.ErrEmpty):
+		return bot.bundle.T(lang, "empty download")
+	default:
+		return err.Error()
+	}
+}
@@ -82,7 +82,7 @@ func (bot *telegramBot) onGLine(ctx tb.Context, settings chatSettings) {
 		}
 
 		bot.deleteMessage(m.ReplyTo, settings, "g-line")
-		bot.banUser(m.Chat, m.ReplyTo.Sender, settings, "g-line")
+		bot.banUser(m.Chat, m.ReplyTo.Sender, settings, 0, "g-line")
 		if err := bot.db.SetUserBanned(m.ReplyTo.Sender.ID); err != nil {
 			bot.logger.WithFields(logfields).WithError(err).Error("Failed to add g-line")
 			return
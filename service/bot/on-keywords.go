@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// onKeywords manages the keyword blocklist of a chat on /keywords command. It
+// works only in private chats, so the blocklist is not revealed in the group:
+//
+//	/keywords <chat ID>               lists all rules
+//	/keywords <chat ID> add <word>    adds a keyword
+//	/keywords <chat ID> regex <expr>  adds a regular expression (RE2 syntax)
+//	/keywords <chat ID> del <rule>    removes a rule (regular expressions are
+//	                                  written between slashes, as listed)
+//
+// The sender must be an admin of the given chat, or a global admin.
+func (bot *telegramBot) onKeywords(ctx tb.Context, settings chatSettings) {
+	m := ctx.Message()
+	if m == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+		return
+	}
+	if !m.Private() {
+		_ = ctx.Delete()
+		return
+	}
+	bot.botCommandsRequestsTotal.WithLabelValues("keywords").Inc()
+
+	lang := ctx.Sender().LanguageCode
+	usage := bot.bundle.T(lang, "Usage:\n/keywords <chat ID>\n/keywords <chat ID> add <keyword>\n/keywords <chat ID> regex <regular expression>\n/keywords <chat ID> del <rule>")
+
+	// The command, the chat ID, the sub-command and the rule (with spaces).
+	parts := strings.SplitN(strings.TrimSpace(m.Text), " ", 4)
+	if len(parts) < 2 {
+		_ = ctx.Send(usage)
+		return
+	}
+	chatID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		_ = ctx.Send(bot.bundle.T(lang, "Invalid ID specified"))
+		return
+	}
+
+	// Only admins of the given chat can see and edit its blocklist.
+	chatsettings, err := bot.db.GetChatSettings(chatID)
+	if errors.Is(err, database.ErrChatNotFound) {
+		_ = ctx.Send(bot.bundle.T(lang, "Chat not found"))
+		return
+	} else if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chatID).Error("Failed to get chat settings")
+		return
+	}
+	isGlobalAdmin, err := bot.db.IsBotAdmin(m.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).Error("Failed to check if the user is a global admin")
+		return
+	}
+	if !isGlobalAdmin && !chatsettings.ChatAdmins.IsAdmin(m.Sender) {
+		_ = ctx.Send(bot.bundle.T(lang, "Sorry, only group admins can use this command"))
+		return
+	}
+
+	if len(parts) == 2 {
+		rules, err := bot.db.GetKeywords(chatID)
+		if err != nil {
+			bot.logger.WithError(err).WithField("chatid", chatID).Error("Failed to get keyword blocklist")
+			_ = ctx.Send(bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+			return
+		}
+		msg := strings.Builder{}
+		msg.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Keyword blocklist for chat %d:\n"), chatID))
+		if len(rules) == 0 {
+			msg.WriteString(bot.bundle.T(lang, "none"))
+		}
+		for _, rule := range rules {
+			msg.WriteString(rule.String() + "\n")
+		}
+		_ = ctx.Send(msg.String())
+		return
+	}
+	if len(parts) < 4 || strings.TrimSpace(parts[3]) == "" {
+		_ = ctx.Send(usage)
+		return
+	}
+
+	pattern := strings.TrimSpace(parts[3])
+	switch parts[2] {
+	case "add":
+		err = bot.db.AddKeyword(chatID, database.KeywordRule{Pattern: pattern})
+	case "regex":
+		if _, err := regexp.Compile(pattern); err != nil {
+			_ = ctx.Send(bot.bundle.T(lang, "Invalid regular expression") + ": " + err.Error())
+			return
+		}
+		err = bot.db.AddKeyword(chatID, database.KeywordRule{Pattern: pattern, Regexp: true})
+	case "del":
+		rule := database.KeywordRule{Pattern: pattern}
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			rule = database.KeywordRule{Pattern: pattern[1 : len(pattern)-1], Regexp: true}
+		}
+		err = bot.db.RemoveKeyword(chatID, rule)
+	default:
+		_ = ctx.Send(usage)
+		return
+	}
+	bot.invalidateKeywordDetector(chatID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chatID).Error("Failed to update keyword blocklist")
+		_ = ctx.Send(bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+		return
+	}
+	_ = ctx.Send("OK")
+}
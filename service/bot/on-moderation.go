@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// moderationCommand is an in-group moderation command: the admin replies to a
+// message, and the action is done on the message or on its author.
+type moderationCommand struct {
+	// Name is the command name, for metrics
+	Name string
+
+	// WithDuration is true if the command accepts a duration as first argument
+	WithDuration bool
+
+	// Do performs the action. The duration is zero if not given (forever)
+	Do func(bot *telegramBot, m *tb.Message, settings chatSettings, duration time.Duration, reason string) error
+}
+
+// onModerationCommand returns the handler for the given moderation command. The
+// handler works only in groups, if the command is given as a reply for another
+// message. Its arguments are an optional duration (e.g. "30m", "2d", only for
+// commands with a duration) and a free-text reason.
+//
+// Chat admins and global admins are skipped.
+func (bot *telegramBot) onModerationCommand(cmd moderationCommand) contextualChatSettingsFunc {
+	return func(ctx tb.Context, settings chatSettings) {
+		m := ctx.Message()
+		if m == nil {
+			bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+			return
+		}
+		_ = ctx.Delete()
+		if m.Private() || !m.IsReply() || m.ReplyTo.Sender == nil || m.ReplyTo.Sender.ID == bot.telebot.Me.ID {
+			return
+		}
+		bot.botCommandsRequestsTotal.WithLabelValues(cmd.Name).Inc()
+		lang := m.Sender.LanguageCode
+
+		isGlobalAdmin, err := bot.db.IsBotAdmin(m.ReplyTo.Sender.ID)
+		if err != nil {
+			bot.logger.WithError(err).Error("Failed to check if the user is a global admin")
+			return
+		}
+		if settings.ChatAdmins.IsAdmin(m.ReplyTo.Sender) || isGlobalAdmin {
+			msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Admins cannot be moderated"))
+			bot.setMessageExpiry(msg, 10*time.Second)
+			return
+		}
+
+		var duration time.Duration
+		args := strings.Fields(m.Payload)
+		if cmd.WithDuration && len(args) > 0 {
+			d, err := parseDuration(args[0])
+			if err == nil {
+				duration = d
+				args = args[1:]
+			} else if errors.Is(err, errDurationRange) {
+				msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "The duration must be between 30 seconds and 366 days"))
+				bot.setMessageExpiry(msg, 10*time.Second)
+				return
+			}
+		}
+		reason := fmt.Sprintf("/%s by %s", cmd.Name, userDisplayName(m.Sender))
+		if len(args) > 0 {
+			reason += ": " + strings.Join(args, " ")
+		}
+
+		if err := cmd.Do(bot, m.ReplyTo, settings, duration, reason); err != nil {
+			msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Sorry, I cannot do that. Please check my permissions in this group"))
+			bot.setMessageExpiry(msg, 10*time.Second)
+			return
+		}
+		msg, _ := bot.telebot.Send(m.Chat, "👍")
+		bot.setMessageExpiry(msg, 10*time.Second)
+	}
+}
+
+// moderationCommands are the in-group moderation commands. Each one is
+// registered as "/" followed by its name.
+var moderationCommands = []moderationCommand{
+	{
+		Name:         "ban",
+		WithDuration: true,
+		Do: func(bot *telegramBot, m *tb.Message, settings chatSettings, duration time.Duration, reason string) error {
+			return bot.banUser(m.Chat, m.Sender, settings, duration, reason)
+		},
+	},
+	{
+		Name:         "mute",
+		WithDuration: true,
+		Do: func(bot *telegramBot, m *tb.Message, settings chatSettings, duration time.Duration, reason string) error {
+			return bot.muteUser(m.Chat, m.Sender, settings, duration, reason)
+		},
+	},
+	{
+		Name: "kick",
+		Do: func(bot *telegramBot, m *tb.Message, settings chatSettings, _ time.Duration, reason string) error {
+			return bot.kickUser(m.Chat, m.Sender, settings, reason)
+		},
+	},
+	{
+		Name: "unban",
+		Do: func(bot *telegramBot, m *tb.Message, settings chatSettings, _ time.Duration, reason string) error {
+			return bot.unbanUser(m.Chat, m.Sender, settings, reason)
+		},
+	},
+	{
+		Name: "unmute",
+		Do: func(bot *telegramBot, m *tb.Message, settings chatSettings, _ time.Duration, reason string) error {
+			return bot.unrestrictUser(m.Chat, m.Sender, settings, reason)
+		},
+	},
+	{
+		Name: "del",
+		Do: func(bot *telegramBot, m *tb.Message, settings chatSettings, _ time.Duration, reason string) error {
+			return bot.deleteMessage(m, settings, reason)
+		},
+	},
+}
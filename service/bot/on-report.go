@@ -0,0 +1,258 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+const (
+	// reportRateLimit is the maximum number of reports that a user can send in
+	// reportRateWindow.
+	reportRateLimit = 3
+
+	// reportRateWindow is the window for reportRateLimit.
+	reportRateWindow = 10 * time.Minute
+)
+
+// Templates of the buttons in report notifications. The data of each button is
+// the report ID.
+var (
+	reportDeleteButton  = tb.InlineButton{Unique: "report_delete"}
+	reportMuteButton    = tb.InlineButton{Unique: "report_mute"}
+	reportBanButton     = tb.InlineButton{Unique: "report_ban"}
+	reportGLineButton   = tb.InlineButton{Unique: "report_gline"}
+	reportDismissButton = tb.InlineButton{Unique: "report_dismiss"}
+)
+
+// onReport is fired on /report command, from any member. It works only in
+// groups, if the command is given as a reply for another message: the message
+// is forwarded to chat admins in private (or to the log channel, if no admin
+// can be reached), with buttons to act on it. The text after the command, if
+// any, is the reason.
+//
+// Reports are rate limited per reporter.
+func (bot *telegramBot) onReport(ctx tb.Context, settings chatSettings) {
+	m := ctx.Message()
+	if m == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+		return
+	}
+	_ = ctx.Delete()
+	if m.Private() || !m.IsReply() || m.ReplyTo.Sender == nil || m.ReplyTo.Sender.ID == bot.telebot.Me.ID {
+		return
+	}
+	bot.botCommandsRequestsTotal.WithLabelValues("report").Inc()
+
+	lang := m.Sender.LanguageCode
+	logfields := logrus.Fields{
+		"chatid":     m.Chat.ID,
+		"userid":     m.ReplyTo.Sender.ID,
+		"reporterid": m.Sender.ID,
+	}
+
+	count, err := bot.db.CountReport(m.Sender.ID, reportRateWindow)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("Failed to count reports")
+		return
+	} else if count > reportRateLimit {
+		msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "You sent too many reports, please try again later"))
+		bot.setMessageExpiry(msg, 10*time.Second)
+		return
+	}
+
+	if settings.ChatAdmins.IsAdmin(m.ReplyTo.Sender) {
+		return
+	}
+
+	report := database.Report{
+		ChatID:     m.Chat.ID,
+		MessageID:  m.ReplyTo.ID,
+		UserID:     m.ReplyTo.Sender.ID,
+		ReporterID: m.Sender.ID,
+		Reason:     m.Payload,
+	}
+	report.ID, err = bot.db.AddReport(report)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("Failed to save report")
+		msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+		bot.setMessageExpiry(msg, 10*time.Second)
+		return
+	}
+
+	// Notifications are in the language of each admin.
+	reportText := func(lang string) string {
+		return fmt.Sprintf(bot.bundle.T(lang, "🚩 Report in %s\nReported user: %s (%d)\nReported by: %s (%d)\nReason: %s"),
+			m.Chat.Title, userDisplayName(m.ReplyTo.Sender), m.ReplyTo.Sender.ID, userDisplayName(m.Sender), m.Sender.ID, report.Reason)
+	}
+
+	for _, adminID := range settings.ChatAdmins {
+		if adminID == bot.telebot.Me.ID {
+			continue
+		}
+		isGlobalAdmin, err := bot.db.IsBotAdmin(adminID)
+		if err != nil {
+			bot.logger.WithError(err).Error("Failed to check if the user is a global admin")
+		}
+		adminLang := bot.userLanguage(adminID)
+		if msg := bot.sendReportNotification(&tb.Chat{ID: adminID}, m.ReplyTo, reportText(adminLang), report.ID, isGlobalAdmin, adminLang); msg != nil {
+			report.Notifications = append(report.Notifications, database.ChatMessage{ChatID: adminID, MessageID: msg.ID})
+		}
+	}
+	if len(report.Notifications) == 0 && settings.LogChannel != 0 {
+		// No admin started a private chat with the bot, use the log channel.
+		if msg := bot.sendReportNotification(&tb.Chat{ID: settings.LogChannel}, m.ReplyTo, reportText(defaultLanguage), report.ID, false, defaultLanguage); msg != nil {
+			report.Notifications = append(report.Notifications, database.ChatMessage{ChatID: settings.LogChannel, MessageID: msg.ID})
+		}
+	}
+	if err := bot.db.SetReport(report); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("Failed to save report notifications")
+	}
+
+	bot.logger.WithFields(logfields).WithField("notifications", len(report.Notifications)).Info("message reported")
+	settings.Log("report", m.Sender, m.ReplyTo.Sender, report.Reason)
+
+	reply := bot.bundle.T(lang, "Thanks, the admins have been notified")
+	if len(report.Notifications) == 0 {
+		reply = bot.bundle.T(lang, "Sorry, I couldn't reach any admin: please contact them directly")
+	}
+	msg, _ := bot.telebot.Send(m.Chat, reply)
+	bot.setMessageExpiry(msg, 10*time.Second)
+}
+
+// sendReportNotification forwards the reported message to the given chat, and
+// sends the report text with the action buttons. It returns the message with
+// the buttons, or nil on error.
+func (bot *telegramBot) sendReportNotification(to *tb.Chat, reported *tb.Message, text string, reportID string, gline bool, lang string) *tb.Message {
+	opts := &tb.SendOptions{}
+	if fwd, err := bot.telebot.Forward(to, reported); err != nil {
+		bot.logger.WithError(err).WithField("chatid", to.ID).Debug("Failed to forward reported message")
+	} else {
+		opts.ReplyTo = fwd
+	}
+
+	buttons := []tb.InlineButton{reportDeleteButton, reportMuteButton, reportBanButton}
+	names := []string{"✂️ " + bot.bundle.T(lang, "Delete"), "🔇 " + bot.bundle.T(lang, "Mute"), "🚷 " + bot.bundle.T(lang, "Ban")}
+	if gline {
+		buttons = append(buttons, reportGLineButton)
+		names = append(names, "🌐 G-Line")
+	}
+	for i := range buttons {
+		buttons[i].Text = names[i]
+		buttons[i].Data = reportID
+	}
+	dismiss := reportDismissButton
+	dismiss.Text = "👌 " + bot.bundle.T(lang, "Dismiss")
+	dismiss.Data = reportID
+	opts.ReplyMarkup = &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{buttons, {dismiss}}}
+
+	msg, err := bot.telebot.Send(to, text, opts)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", to.ID).Debug("Failed to send report notification")
+		return nil
+	}
+	return msg
+}
+
+// onReportAction is fired when an admin presses a button of a report
+// notification. The first admin to act closes the report: the action is done
+// on the reported message and user, and all notifications are updated.
+func (bot *telegramBot) onReportAction(ctx tb.Context) error {
+	callback := ctx.Callback()
+	if callback == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Callback, ignored")
+		return nil
+	}
+	lang := callback.Sender.LanguageCode
+
+	report, err := bot.db.GetReport(callback.Data)
+	if errors.Is(err, database.ErrReportNotFound) {
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "This report has already been handled")})
+	} else if err != nil {
+		bot.logger.WithError(err).Error("Failed to get report")
+		return ctx.Respond()
+	}
+
+	chat := &tb.Chat{ID: report.ChatID}
+	settings, err := bot.getChatSettings(chat)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Cannot get chat settings")
+		return ctx.Respond()
+	}
+	isGlobalAdmin, err := bot.db.IsBotAdmin(callback.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).Error("Failed to check if the user is a global admin")
+		return ctx.Respond()
+	}
+	unique := callback.Unique
+	if (!settings.ChatAdmins.IsAdmin(callback.Sender) && !isGlobalAdmin) || (unique == reportGLineButton.Unique && !isGlobalAdmin) {
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "Sorry, only group admins can use this command")})
+	}
+
+	// Only the first admin that closes the report acts on it.
+	report, err = bot.db.CloseReport(report.ID)
+	if errors.Is(err, database.ErrReportNotFound) {
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "This report has already been handled")})
+	} else if err != nil {
+		bot.logger.WithError(err).Error("Failed to close report")
+		return ctx.Respond()
+	}
+
+	user := &tb.User{ID: report.UserID}
+	msg := &tb.Message{ID: report.MessageID, Chat: chat, Sender: user}
+	reason := "Reported by a member"
+	if report.Reason != "" {
+		reason += ": " + report.Reason
+	}
+
+	// The outcome name is translated for each notification.
+	var icon, outcome string
+	switch unique {
+	case reportDeleteButton.Unique:
+		icon, outcome = "✂️", "Delete"
+		_ = bot.deleteMessage(msg, settings, reason)
+	case reportMuteButton.Unique:
+		icon, outcome = "🔇", "Mute"
+		_ = bot.muteUser(chat, user, settings, 0, reason)
+		_ = bot.deleteMessage(msg, settings, reason)
+	case reportBanButton.Unique:
+		icon, outcome = "🚷", "Ban"
+		_ = bot.banUser(chat, user, settings, 0, reason)
+		_ = bot.deleteMessage(msg, settings, reason)
+	case reportGLineButton.Unique:
+		icon, outcome = "🌐", "G-Line"
+		if isTargetAdmin, err := bot.db.IsBotAdmin(user.ID); err != nil || isTargetAdmin {
+			bot.logger.WithField("userid", user.ID).Warn("Won't g-line a global admin")
+			break
+		}
+		if err := bot.db.SetUserBanned(user.ID); err != nil {
+			bot.logger.WithError(err).WithField("userid", user.ID).Error("Failed to add g-line")
+		}
+		_ = bot.banUser(chat, user, settings, 0, "g-line: "+reason)
+		_ = bot.deleteMessage(msg, settings, reason)
+	default:
+		icon, outcome = "👌", "Dismiss"
+	}
+	_ = ctx.Respond(&tb.CallbackResponse{Text: "Ok"})
+
+	bot.logger.WithFields(logrus.Fields{
+		"chatid":  chat.ID,
+		"userid":  user.ID,
+		"adminid": callback.Sender.ID,
+		"action":  unique,
+	}).Info("report closed")
+
+	for _, n := range report.Notifications {
+		nlang := bot.userLanguage(n.ChatID)
+		text := fmt.Sprintf(bot.bundle.T(nlang, "✅ Report closed by %s: %s"), userDisplayName(callback.Sender), icon+" "+bot.bundle.T(nlang, outcome))
+		if _, err := bot.telebot.Edit(&tb.Message{ID: n.MessageID, Chat: &tb.Chat{ID: n.ChatID}}, text); err != nil {
+			bot.logger.WithError(err).WithField("chatid", n.ChatID).Debug("Failed to update report notification")
+		}
+	}
+	return nil
+}
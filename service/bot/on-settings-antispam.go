@@ -26,7 +26,7 @@ func (bot *telegramBot) sendAntispamSettingsMessage(m *tb.Message, lang string,
 	buf.WriteString(prettyActionName(settings.OnJoinChinese, bot, lang))
 	buf.WriteString("*\n")
 	buf.WriteString(bot.bundle.T(lang, "On message: *"))
-	buf.WriteString(prettyActionName(settings.OnMessageChinese, bot, lang))
+	buf.WriteString(prettyActionName(settings.Detector("chinese").Action, bot, lang))
 	buf.WriteString("*\n")
 
 	buf.WriteString("\n☪️  " + bot.bundle.T(lang, "*Arabic* blocker:\n"))
@@ -34,7 +34,62 @@ func (bot *telegramBot) sendAntispamSettingsMessage(m *tb.Message, lang string,
 	buf.WriteString(prettyActionName(settings.OnJoinArabic, bot, lang))
 	buf.WriteString("*\n")
 	buf.WriteString(bot.bundle.T(lang, "On message: *"))
-	buf.WriteString(prettyActionName(settings.OnMessageArabic, bot, lang))
+	buf.WriteString(prettyActionName(settings.Detector("arabic").Action, bot, lang))
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n🔤 " + bot.bundle.T(lang, "*Script* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(settings.Detector("script").Action, bot, lang))
+	buf.WriteString("*")
+	if len(settings.Scripts) > 0 {
+		buf.WriteString(" (" + strings.Join(settings.Scripts, ", ") + ")")
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString("\n🔗 " + bot.bundle.T(lang, "*Link* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(settings.Detector("links").Action, bot, lang))
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n📝 " + bot.bundle.T(lang, "*Keyword* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(settings.Detector("keywords").Action, bot, lang))
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n🔠 " + bot.bundle.T(lang, "*Look-alike letters* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(settings.Detector("confusables").Action, bot, lang))
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n🌊 " + bot.bundle.T(lang, "*Flood* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On flood: *"))
+	buf.WriteString(prettyActionName(settings.OnFlood, bot, lang))
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n👯 " + bot.bundle.T(lang, "*Duplicates* across the network:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(settings.OnDuplicate, bot, lang))
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n🐣 " + bot.bundle.T(lang, "*Probation* for new members: *"))
+	if settings.Probation.Enabled {
+		buf.WriteString(bot.bundle.T(lang, "enabled"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "disabled"))
+	}
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n🧩 " + bot.bundle.T(lang, "*CAPTCHA* for new members: *"))
+	if settings.Captcha.Enabled {
+		buf.WriteString(bot.bundle.T(lang, "enabled"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "disabled"))
+	}
+	buf.WriteString("*\n")
+
+	buf.WriteString("\n🤖 " + bot.bundle.T(lang, "*Spam* classifier:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(settings.Detector("spam").Action, bot, lang))
 	buf.WriteString("*\n")
 
 	buf.WriteString(bot.bundle.T(lang, "\nCAS-ban (see https://combot.org/cas/ ):\n"))
@@ -101,7 +156,7 @@ func (bot *telegramBot) sendAntispamSettingsMessage(m *tb.Message, lang string,
 
 	// On Message Chinese (TODO: add ban action)
 	onMessageChineseKickButtonText := "✅ " + bot.bundle.T(lang, "Kick Chinese msgs")
-	if settings.OnMessageChinese.Action != database.ActionNone {
+	if settings.Detector("chinese").Action.Action != database.ActionNone {
 		onMessageChineseKickButtonText = "❌ " + bot.bundle.T(lang, "Don't kick chinese msgs")
 	}
 	onMessageChineseKickButton := tb.InlineButton{
@@ -110,21 +165,19 @@ func (bot *telegramBot) sendAntispamSettingsMessage(m *tb.Message, lang string,
 		Data:   strconv.FormatInt(chatToConfigure.ID, 10),
 	}
 	bot.handleAdminCallbackStateful(&onMessageChineseKickButton, bot.callbackAntispamSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
-		if settings.OnMessageChinese.Action == database.ActionNone {
-			settings.OnMessageChinese = database.BotAction{
-				Action: database.ActionKick,
-			}
+		if settings.Detector("chinese").Action.Action == database.ActionNone {
+			settings.SetDetector("chinese", database.DetectorSettings{
+				Action: database.BotAction{Action: database.ActionKick},
+			})
 		} else {
-			settings.OnMessageChinese = database.BotAction{
-				Action: database.ActionNone,
-			}
+			settings.SetDetector("chinese", database.DetectorSettings{})
 		}
 		return settings
 	}))
 
 	// On Message Arabic (TODO: add ban action)
 	onMessageArabicKickButtonText := "✅ " + bot.bundle.T(lang, "Kick Arabic msgs")
-	if settings.OnMessageArabic.Action != database.ActionNone {
+	if settings.Detector("arabic").Action.Action != database.ActionNone {
 		onMessageArabicKickButtonText = "❌ " + bot.bundle.T(lang, "Don't kick arabs msgs")
 	}
 	onMessageArabicKickButton := tb.InlineButton{
@@ -133,14 +186,12 @@ func (bot *telegramBot) sendAntispamSettingsMessage(m *tb.Message, lang string,
 		Data:   strconv.FormatInt(chatToConfigure.ID, 10),
 	}
 	bot.handleAdminCallbackStateful(&onMessageArabicKickButton, bot.callbackAntispamSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
-		if settings.OnMessageArabic.Action == database.ActionNone {
-			settings.OnMessageArabic = database.BotAction{
-				Action: database.ActionKick,
-			}
+		if settings.Detector("arabic").Action.Action == database.ActionNone {
+			settings.SetDetector("arabic", database.DetectorSettings{
+				Action: database.BotAction{Action: database.ActionKick},
+			})
 		} else {
-			settings.OnMessageArabic = database.BotAction{
-				Action: database.ActionNone,
-			}
+			settings.SetDetector("arabic", database.DetectorSettings{})
 		}
 		return settings
 	}))
@@ -168,11 +219,236 @@ func (bot *telegramBot) sendAntispamSettingsMessage(m *tb.Message, lang string,
 		return settings
 	}))
 
+	// Script blocker panel
+	scriptsButton := tb.InlineButton{
+		Unique: "settings_goto_scripts",
+		Text:   "🔤 " + bot.bundle.T(lang, "Scripts"),
+	}
+	bot.handleAdminCallbackStateful(&scriptsButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendScriptSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Link blocker panel
+	linksButton := tb.InlineButton{
+		Unique: "settings_goto_links",
+		Text:   "🔗 " + bot.bundle.T(lang, "Links"),
+	}
+	bot.handleAdminCallbackStateful(&linksButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendLinkSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Keyword blocker panel
+	keywordsButton := tb.InlineButton{
+		Unique: "settings_goto_keywords",
+		Text:   "📝 " + bot.bundle.T(lang, "Keywords"),
+	}
+	bot.handleAdminCallbackStateful(&keywordsButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendKeywordSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Flood blocker panel
+	floodButton := tb.InlineButton{
+		Unique: "settings_goto_flood",
+		Text:   "🌊 " + bot.bundle.T(lang, "Flood"),
+	}
+	bot.handleAdminCallbackStateful(&floodButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendFloodSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Look-alike letters: each click selects the next action.
+	confusablesButton := tb.InlineButton{
+		Unique: "settings_confusables_action",
+		Text:   "🔠 " + bot.bundle.T(lang, "Look-alikes: ") + prettyActionName(settings.Detector("confusables").Action, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&confusablesButton, bot.callbackAntispamSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		detector := settings.Detector("confusables")
+		detector.Action = nextAction(detector.Action)
+		settings.SetDetector("confusables", detector)
+		return settings
+	}))
+
+	// Network duplicates: each click selects the next action.
+	duplicatesButton := tb.InlineButton{
+		Unique: "settings_duplicates_action",
+		Text:   "👯 " + bot.bundle.T(lang, "Duplicates: ") + prettyActionName(settings.OnDuplicate, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&duplicatesButton, bot.callbackAntispamSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.OnDuplicate = nextAction(settings.OnDuplicate)
+		return settings
+	}))
+
+	// Probation panel
+	probationButton := tb.InlineButton{
+		Unique: "settings_goto_probation",
+		Text:   "🐣 " + bot.bundle.T(lang, "Probation"),
+	}
+	bot.handleAdminCallbackStateful(&probationButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendProbationSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// CAPTCHA panel
+	captchaButton := tb.InlineButton{
+		Unique: "settings_goto_captcha",
+		Text:   "🧩 " + bot.bundle.T(lang, "CAPTCHA"),
+	}
+	bot.handleAdminCallbackStateful(&captchaButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendCaptchaSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Join policy panel
+	joinButton := tb.InlineButton{
+		Unique: "settings_goto_join",
+		Text:   "👤 " + bot.bundle.T(lang, "Join policy"),
+	}
+	bot.handleAdminCallbackStateful(&joinButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendJoinSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Content types panel
+	contentButton := tb.InlineButton{
+		Unique: "settings_goto_content",
+		Text:   "📦 " + bot.bundle.T(lang, "Content types"),
+	}
+	bot.handleAdminCallbackStateful(&contentButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendContentSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Strikes panel
+	strikesButton := tb.InlineButton{
+		Unique: "settings_goto_strikes",
+		Text:   "🪜 " + bot.bundle.T(lang, "Strikes"),
+	}
+	bot.handleAdminCallbackStateful(&strikesButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendStrikesSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Durations panel
+	durationsButton := tb.InlineButton{
+		Unique: "settings_goto_durations",
+		Text:   "⏳ " + bot.bundle.T(lang, "Mute and ban durations"),
+	}
+	bot.handleAdminCallbackStateful(&durationsButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendDurationSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings, 0)
+	})
+
+	// Raid detection panel
+	raidButton := tb.InlineButton{
+		Unique: "settings_goto_raid",
+		Text:   "🚨 " + bot.bundle.T(lang, "Raid detection"),
+	}
+	bot.handleAdminCallbackStateful(&raidButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendRaidSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Quiet hours panel
+	quietHoursButton := tb.InlineButton{
+		Unique: "settings_goto_quiet",
+		Text:   "🌙 " + bot.bundle.T(lang, "Quiet hours"),
+	}
+	bot.handleAdminCallbackStateful(&quietHoursButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendQuietHoursSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Formatting abuse panel
+	formattingButton := tb.InlineButton{
+		Unique: "settings_goto_formatting",
+		Text:   "🎨 " + bot.bundle.T(lang, "Formatting abuse"),
+	}
+	bot.handleAdminCallbackStateful(&formattingButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendFormattingSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Ban lists panel
+	banListsButton := tb.InlineButton{
+		Unique: "settings_goto_banlists",
+		Text:   "🚷 " + bot.bundle.T(lang, "Ban lists"),
+	}
+	bot.handleAdminCallbackStateful(&banListsButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendBanListSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	// Spam classifier panel
+	classifierButton := tb.InlineButton{
+		Unique: "settings_goto_classifier",
+		Text:   "🤖 " + bot.bundle.T(lang, "Spam classifier"),
+	}
+	bot.handleAdminCallbackStateful(&classifierButton, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendClassifierSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
 	reply := &tb.ReplyMarkup{
 		InlineKeyboard: [][]tb.InlineButton{
 			{onJoinChineseKickButton, onJoinArabicKickButton},
 			{onMessageChineseKickButton, onMessageArabicKickButton},
-			{enableCASbutton},
+			{scriptsButton, linksButton},
+			{keywordsButton, floodButton},
+			{confusablesButton, duplicatesButton},
+			{captchaButton, probationButton},
+			{classifierButton, enableCASbutton},
+			{contentButton, strikesButton},
+			{joinButton, durationsButton},
+			{raidButton, quietHoursButton},
+			{formattingButton, banListsButton},
 			{backBtn},
 		},
 	}
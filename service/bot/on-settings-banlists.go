@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// sendBanListSettingsMessage sends the ban lists settings panel, editing the
+// given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the ban lists button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendBanListSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🚷 " + bot.bundle.T(lang, "*Ban lists*: lists of spammers published by anti-spam communities.\n"))
+	buf.WriteString(bot.bundle.T(lang, "Press a button to change the action on users in that list.\n"))
+
+	var keyboard [][]tb.InlineButton
+	for _, list := range bot.banlists {
+		bt := tb.InlineButton{
+			Unique: "settings_banlist_" + list.Name(),
+			Text:   list.Name() + ": " + prettyActionName(settings.BanListAction(list.Name()), bot, lang),
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackBanListSettings(func(name string) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.SetBanListAction(name, nextAction(settings.BanListAction(name)))
+				return settings
+			}
+		}(list.Name())))
+		keyboard = append(keyboard, []tb.InlineButton{bt})
+	}
+	if len(bot.banlists) == 0 {
+		buf.WriteString(bot.bundle.T(lang, "No ban list is configured."))
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_banlist_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackBanListSettings is like callbackAntispamSettings, but it goes back to
+// the ban lists settings panel.
+func (bot *telegramBot) callbackBanListSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to ban lists settings
+		bot.sendBanListSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// captchaTimeoutOptions are the selectable times to answer in the CAPTCHA
+// settings panel, in seconds.
+var captchaTimeoutOptions = []uint{60, 120, 300}
+
+// sendCaptchaSettingsMessage sends the join CAPTCHA settings panel, editing the
+// given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the CAPTCHA button, inside
+// the antispam settings panel.
+func (bot *telegramBot) sendCaptchaSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	timeout := settings.Captcha.Timeout
+	if timeout == 0 {
+		timeout = uint(defaultCaptchaTimeout / time.Second)
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🧩 " + bot.bundle.T(lang, "*CAPTCHA* for new members:\n"))
+	buf.WriteString(bot.bundle.T(lang, "New members are muted until they answer a simple question. Who does not answer in time, or answers wrong, is kicked.\n\n"))
+	if settings.Captcha.Enabled {
+		buf.WriteString(bot.bundle.T(lang, "Status: *enabled*\n"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "Status: *disabled*\n"))
+	}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Time to answer: *%s*\n"), prettyDuration(timeout, bot, lang)))
+
+	var keyboard [][]tb.InlineButton
+
+	// Enable/disable button.
+	enableBtnText := "❌ " + bot.bundle.T(lang, "CAPTCHA disabled")
+	if settings.Captcha.Enabled {
+		enableBtnText = "✅ " + bot.bundle.T(lang, "CAPTCHA enabled")
+	}
+	enableBtn := tb.InlineButton{
+		Unique: "settings_captcha_enable",
+		Text:   enableBtnText,
+	}
+	bot.handleAdminCallbackStateful(&enableBtn, bot.callbackCaptchaSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.Captcha.Enabled = !settings.Captcha.Enabled
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{enableBtn})
+
+	// Timeout buttons.
+	var row []tb.InlineButton
+	for _, option := range captchaTimeoutOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_captcha_timeout_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "⏱ " + prettyDuration(option, bot, lang),
+		}
+		if option == timeout {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackCaptchaSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Captcha.Timeout = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_captcha_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackCaptchaSettings is like callbackAntispamSettings, but it goes back to
+// the CAPTCHA settings panel.
+func (bot *telegramBot) callbackCaptchaSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to CAPTCHA settings
+		bot.sendCaptchaSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
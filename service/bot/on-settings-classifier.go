@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// selectableSpamThresholds is the list of thresholds that chat admins can choose
+// for the spam classifier.
+var selectableSpamThresholds = []float64{0.8, 0.9, 0.95, 0.99}
+
+// sendClassifierSettingsMessage sends the spam classifier settings panel,
+// editing the given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the classifier button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendClassifierSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	detector := settings.Detector("spam")
+	threshold := detector.Threshold
+	if threshold == 0 {
+		threshold = 0.9
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🤖 " + bot.bundle.T(lang, "*Spam* classifier:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(detector.Action, bot, lang))
+	buf.WriteString("*\n")
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Threshold: *%.0f%%* spam probability\n"), threshold*100))
+	buf.WriteString("\n" + bot.bundle.T(lang, "Admins can train the classifier by replying to a message with /spam (the message will be deleted) or /ham (the message is not spam). The training is shared by all groups."))
+
+	var keyboard [][]tb.InlineButton
+
+	// Action button: each click selects the next action.
+	actionBtn := tb.InlineButton{
+		Unique: "settings_classifier_action",
+		Text:   bot.bundle.T(lang, "Action: ") + prettyActionName(detector.Action, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&actionBtn, bot.callbackClassifierSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		detector := settings.Detector("spam")
+		detector.Action = nextAction(detector.Action)
+		settings.SetDetector("spam", detector)
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{actionBtn})
+
+	// Threshold buttons.
+	var row []tb.InlineButton
+	for _, t := range selectableSpamThresholds {
+		text := strconv.FormatFloat(t*100, 'f', 0, 64) + "%"
+		if t == threshold {
+			text = "✅ " + text
+		}
+		bt := tb.InlineButton{
+			Unique: "settings_classifier_threshold_" + strconv.FormatFloat(t*100, 'f', 0, 64),
+			Text:   text,
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackClassifierSettings(func(t float64) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				detector := settings.Detector("spam")
+				detector.Threshold = t
+				settings.SetDetector("spam", detector)
+				return settings
+			}
+		}(t)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_classifier_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackClassifierSettings is like callbackAntispamSettings, but it goes back
+// to the spam classifier settings panel.
+func (bot *telegramBot) callbackClassifierSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to classifier settings
+		bot.sendClassifierSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
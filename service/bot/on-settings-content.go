@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// recentWindowOptions are the selectable recent member windows, in seconds.
+var recentWindowOptions = []uint{3600, 24 * 3600, 3 * 24 * 3600, 7 * 24 * 3600}
+
+// sendContentSettingsMessage sends the content types settings panel, editing
+// the given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the content types button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendContentSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	recentWindow := uint(defaultRecentWindow.Seconds())
+	if settings.Content.RecentWindow > 0 {
+		recentWindow = settings.Content.RecentWindow
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("📦 " + bot.bundle.T(lang, "*Content types* policy:\n"))
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Each content type can be allowed, blocked, or blocked for members that joined in the last %s.\n\n"), prettyDuration(recentWindow, bot, lang)))
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Action: *%s*\n"), prettyActionName(settings.Content.Action, bot, lang)))
+
+	var keyboard [][]tb.InlineButton
+
+	// One button per content type, cycling its rule.
+	for _, ct := range contentTypes {
+		bt := tb.InlineButton{
+			Unique: "settings_content_" + ct.Key,
+			Text:   bot.bundle.T(lang, ct.Name) + ": " + prettyContentRule(settings.Content.Rules[ct.Key], bot, lang),
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackContentSettings(func(key string) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				rules := make(map[string]int, len(settings.Content.Rules)+1)
+				for k, v := range settings.Content.Rules {
+					rules[k] = v
+				}
+				rules[key] = (rules[key] + 1) % 3
+				if rules[key] == database.ContentAllow {
+					delete(rules, key)
+				}
+				settings.Content.Rules = rules
+				if len(rules) > 0 && settings.Content.Action.Action == database.ActionNone {
+					settings.Content.Action = database.BotAction{Action: database.ActionDeleteMsg}
+				}
+				return settings
+			}
+		}(ct.Key)))
+		keyboard = append(keyboard, []tb.InlineButton{bt})
+	}
+
+	// Action button.
+	actionBtn := tb.InlineButton{
+		Unique: "settings_content_action",
+		Text:   bot.bundle.T(lang, "Action: ") + prettyActionName(settings.Content.Action, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&actionBtn, bot.callbackContentSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.Content.Action = nextAction(settings.Content.Action)
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{actionBtn})
+
+	// Recent window buttons.
+	var row []tb.InlineButton
+	for _, option := range recentWindowOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_content_recent_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "🐣 " + prettyDuration(option, bot, lang),
+		}
+		if option == recentWindow {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackContentSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Content.RecentWindow = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_content_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackContentSettings is like callbackAntispamSettings, but it goes back
+// to the content types settings panel.
+func (bot *telegramBot) callbackContentSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to content types settings
+		bot.sendContentSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
+
+// prettyContentRule returns an human-friendly name for the given content type
+// rule.
+func prettyContentRule(rule int, bot *telegramBot, lang string) string {
+	switch rule {
+	case database.ContentBlock:
+		return "🚫 " + bot.bundle.T(lang, "blocked")
+	case database.ContentBlockRecent:
+		return "🐣 " + bot.bundle.T(lang, "blocked for new members")
+	default:
+		return "✅ " + bot.bundle.T(lang, "allowed")
+	}
+}
@@ -0,0 +1,312 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/cas"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// durationOptions are the selectable durations for mutes and bans, in seconds.
+// Zero means forever.
+var durationOptions = []uint{0, 3600, 24 * 3600, 7 * 24 * 3600}
+
+// durationsPageSize is the number of actions in each page of the durations
+// panel. Each action has a row for the label and one for the durations, and
+// Telegram accepts at most 100 buttons in a message.
+const durationsPageSize = 8
+
+// configurableAction is an action in chat settings that can be configured in
+// the durations panel.
+type configurableAction struct {
+	// Key is the unique key of the action
+	Key string
+
+	// Label is the (localizable) name of the action
+	Label string
+
+	// Get returns the action from chat settings
+	Get func(settings *chatSettings) database.BotAction
+
+	// Set saves the action in chat settings
+	Set func(settings *chatSettings, action database.BotAction)
+}
+
+// detectorAction returns the configurableAction for the action of the given
+// detector.
+func detectorAction(name string, label string) configurableAction {
+	return configurableAction{
+		Key:   name,
+		Label: label,
+		Get: func(settings *chatSettings) database.BotAction {
+			return settings.Detector(name).Action
+		},
+		Set: func(settings *chatSettings, action database.BotAction) {
+			detector := settings.Detector(name)
+			detector.Action = action
+			settings.SetDetector(name, detector)
+		},
+	}
+}
+
+// configurableActions are all actions that can be configured in the durations
+// panel. Actions with the "join_" key prefix are also in the join policy panel.
+var configurableActions = []configurableAction{
+	{
+		Key:   "join_chinese",
+		Label: "Chinese on join",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnJoinChinese },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnJoinChinese = action },
+	},
+	{
+		Key:   "join_arabic",
+		Label: "Arabic on join",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnJoinArabic },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnJoinArabic = action },
+	},
+	{
+		Key:   "join_name_link",
+		Label: "Links in name",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnJoinNameLink },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnJoinNameLink = action },
+	},
+	{
+		Key:   "join_symbol_name",
+		Label: "Name without letters",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnJoinSymbolName },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnJoinSymbolName = action },
+	},
+	{
+		Key:   "join_no_username",
+		Label: "No username",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnJoinNoUsername },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnJoinNoUsername = action },
+	},
+	{
+		Key:   "join_bio_link",
+		Label: "Links in bio",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnJoinBioLink },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnJoinBioLink = action },
+	},
+	detectorAction("chinese", "Chinese messages"),
+	detectorAction("arabic", "Arabic messages"),
+	detectorAction("script", "Scripts"),
+	detectorAction("links", "Links"),
+	detectorAction("keywords", "Keywords"),
+	detectorAction("confusables", "Look-alike letters"),
+	detectorAction("spam", "Spam classifier"),
+	detectorAction("mentions", "Mentions"),
+	detectorAction("emojis", "Emojis"),
+	detectorAction("caps", "Capital letters"),
+	detectorAction("zalgo", "Zalgo text"),
+	detectorAction("invisible", "Invisible characters"),
+	{
+		Key:   "flood",
+		Label: "Flood",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnFlood },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnFlood = action },
+	},
+	{
+		Key:   "duplicate",
+		Label: "Duplicates",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnDuplicate },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnDuplicate = action },
+	},
+	{
+		Key:   "cas",
+		Label: "CAS",
+		Get:   func(settings *chatSettings) database.BotAction { return settings.OnBlacklistCAS },
+		Set:   func(settings *chatSettings, action database.BotAction) { settings.OnBlacklistCAS = action },
+	},
+}
+
+// banListAction returns the configurableAction for the action of the given ban
+// list.
+func banListAction(name string) configurableAction {
+	return configurableAction{
+		Key:   "banlist_" + name,
+		Label: name,
+		Get: func(settings *chatSettings) database.BotAction {
+			return settings.BanListAction(name)
+		},
+		Set: func(settings *chatSettings, action database.BotAction) {
+			settings.SetBanListAction(name, action)
+		},
+	}
+}
+
+// banListActions returns the configurableAction for each ban list, except CAS
+// (already in configurableActions).
+func (bot *telegramBot) banListActions() []configurableAction {
+	var ret []configurableAction
+	for _, list := range bot.banlists {
+		if list.Name() != cas.Name {
+			ret = append(ret, banListAction(list.Name()))
+		}
+	}
+	return ret
+}
+
+// sendDurationSettingsMessage sends the given page of the durations settings
+// panel, editing the given message and localizing the text with the given
+// language. It lists all actions that mute or ban users, durationsPageSize per
+// page, and for each one the selectable durations.
+//
+// This panel can be accessed when the user clicks on the durations button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendDurationSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings, page int) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("⏳ " + bot.bundle.T(lang, "*Durations* of mutes and bans:\n"))
+
+	// Only actions that mute or ban users have a duration. Their index is used
+	// in button uniques instead of their key, as callback data is limited to
+	// 64 bytes.
+	var indexes []int
+	all := append(append([]configurableAction(nil), configurableActions...), bot.banListActions()...)
+	for i, ca := range all {
+		if action := ca.Get(&settings); action.Action == database.ActionMute || action.Action == database.ActionBan {
+			indexes = append(indexes, i)
+		}
+	}
+	pages := (len(indexes) + durationsPageSize - 1) / durationsPageSize
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	pageData := strconv.Itoa(page)
+
+	var keyboard [][]tb.InlineButton
+	for n, i := range indexes {
+		if n/durationsPageSize != page {
+			continue
+		}
+		ca := all[i]
+		action := ca.Get(&settings)
+		buf.WriteString(fmt.Sprintf("%s: %s *%s*\n", bot.bundle.T(lang, ca.Label), prettyActionName(action, bot, lang), prettyActionDuration(action.Duration, bot, lang)))
+
+		var row []tb.InlineButton
+		for j, option := range durationOptions {
+			bt := tb.InlineButton{
+				Unique: "settings_duration_" + strconv.Itoa(i) + "_" + strconv.Itoa(j),
+				Text:   prettyActionDuration(option, bot, lang),
+				Data:   pageData,
+			}
+			if option == action.Duration {
+				bt.Text = "✅ " + bt.Text
+			}
+			bot.handleAdminCallbackStateful(&bt, bot.callbackDurationSettings(func(ca configurableAction, option uint) func(tb.Context, chatSettings) chatSettings {
+				return func(ctx tb.Context, settings chatSettings) chatSettings {
+					action := ca.Get(&settings)
+					action.Duration = option
+					ca.Set(&settings, action)
+					return settings
+				}
+			}(ca, option)))
+			row = append(row, bt)
+		}
+
+		// The label button does nothing, it only shows the action name.
+		labelBtn := tb.InlineButton{
+			Unique: "settings_duration_label_" + strconv.Itoa(i),
+			Text:   "⬇️ " + bot.bundle.T(lang, ca.Label) + " ⬇️",
+			Data:   pageData,
+		}
+		bot.handleAdminCallbackStateful(&labelBtn, bot.callbackDurationSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+			return settings
+		}))
+		keyboard = append(keyboard, []tb.InlineButton{labelBtn}, row)
+	}
+	if len(keyboard) == 0 {
+		buf.WriteString(bot.bundle.T(lang, "No action mutes or bans users."))
+	}
+
+	// Pages buttons.
+	var pageRow []tb.InlineButton
+	if page > 0 {
+		prevBtn := tb.InlineButton{
+			Unique: "settings_duration_prev",
+			Text:   "⬅️ " + bot.bundle.T(lang, "Prev"),
+			Data:   strconv.Itoa(page - 1),
+		}
+		bot.handleAdminCallbackStateful(&prevBtn, bot.callbackDurationSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+			return settings
+		}))
+		pageRow = append(pageRow, prevBtn)
+	}
+	if page < pages-1 {
+		nextBtn := tb.InlineButton{
+			Unique: "settings_duration_next",
+			Text:   bot.bundle.T(lang, "Next") + " ➡️",
+			Data:   strconv.Itoa(page + 1),
+		}
+		bot.handleAdminCallbackStateful(&nextBtn, bot.callbackDurationSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+			return settings
+		}))
+		pageRow = append(pageRow, nextBtn)
+	}
+	if len(pageRow) > 0 {
+		keyboard = append(keyboard, pageRow)
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_duration_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackDurationSettings is like callbackAntispamSettings, but it goes back
+// to the page of the durations settings panel in the button data.
+func (bot *telegramBot) callbackDurationSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to durations settings
+		page, _ := strconv.Atoi(callback.Data)
+		bot.sendDurationSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings, page)
+	}
+}
+
+// prettyActionDuration returns an human-friendly name for the given action
+// duration in seconds, where zero means "forever".
+func prettyActionDuration(seconds uint, bot *telegramBot, lang string) string {
+	if seconds == 0 {
+		return bot.bundle.T(lang, "forever")
+	}
+	return prettyDuration(seconds, bot, lang)
+}
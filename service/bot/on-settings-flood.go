@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// Selectable values in the flood settings panel. Zero means "no limit".
+var (
+	floodMessagesOptions = []uint{0, 5, 10, 20}
+	floodRepeatsOptions  = []uint{0, 2, 3, 5}
+	floodWindowOptions   = []uint{10, 30, 60}
+)
+
+// defaultFloodSettings are the limits used when the flood detection is enabled
+// for the first time.
+var defaultFloodSettings = database.FloodSettings{
+	Messages: 10,
+	Repeats:  3,
+	Window:   30,
+}
+
+// sendFloodSettingsMessage sends the flood settings panel, editing the given
+// message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the flood button, inside
+// the antispam settings panel.
+func (bot *telegramBot) sendFloodSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🌊 " + bot.bundle.T(lang, "*Flood* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On flood: *"))
+	buf.WriteString(prettyActionName(settings.OnFlood, bot, lang))
+	buf.WriteString("*\n")
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Max messages: *%s* in %d seconds\n"), prettyLimit(settings.Flood.Messages, bot, lang), settings.Flood.Window))
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Max identical messages: *%s* in %d seconds\n"), prettyLimit(settings.Flood.Repeats, bot, lang), settings.Flood.Window))
+
+	var keyboard [][]tb.InlineButton
+
+	// Action button: each click selects the next action.
+	actionBtn := tb.InlineButton{
+		Unique: "settings_flood_action",
+		Text:   bot.bundle.T(lang, "Action: ") + prettyActionName(settings.OnFlood, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&actionBtn, bot.callbackFloodSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.OnFlood = nextAction(settings.OnFlood)
+		if settings.Flood.Window == 0 {
+			settings.Flood = defaultFloodSettings
+		}
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{actionBtn})
+
+	keyboard = append(keyboard, bot.floodOptionButtons("messages", floodMessagesOptions, settings.Flood.Messages, func(v uint) string {
+		return "✉️ " + prettyLimit(v, bot, lang)
+	}, func(flood *database.FloodSettings, v uint) {
+		flood.Messages = v
+	}))
+	keyboard = append(keyboard, bot.floodOptionButtons("repeats", floodRepeatsOptions, settings.Flood.Repeats, func(v uint) string {
+		return "🔁 " + prettyLimit(v, bot, lang)
+	}, func(flood *database.FloodSettings, v uint) {
+		flood.Repeats = v
+	}))
+	keyboard = append(keyboard, bot.floodOptionButtons("window", floodWindowOptions, settings.Flood.Window, func(v uint) string {
+		return "⏱ " + strconv.FormatUint(uint64(v), 10) + "s"
+	}, func(flood *database.FloodSettings, v uint) {
+		flood.Window = v
+	}))
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_flood_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// floodOptionButtons returns a row of buttons, one for each option. The button
+// of the current value is marked, and each button calls set with its value.
+func (bot *telegramBot) floodOptionButtons(name string, options []uint, current uint, text func(uint) string, set func(*database.FloodSettings, uint)) []tb.InlineButton {
+	var row []tb.InlineButton
+	for _, option := range options {
+		bt := tb.InlineButton{
+			Unique: "settings_flood_" + name + "_" + strconv.FormatUint(uint64(option), 10),
+			Text:   text(option),
+		}
+		if option == current {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackFloodSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				set(&settings.Flood, option)
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	return row
+}
+
+// callbackFloodSettings is like callbackAntispamSettings, but it goes back to
+// the flood settings panel.
+func (bot *telegramBot) callbackFloodSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to flood settings
+		bot.sendFloodSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
+
+// prettyLimit returns an human-friendly name for the given limit, where zero
+// means "no limit".
+func prettyLimit(limit uint, bot *telegramBot, lang string) string {
+	if limit == 0 {
+		return bot.bundle.T(lang, "no limit")
+	}
+	return strconv.FormatUint(uint64(limit), 10)
+}
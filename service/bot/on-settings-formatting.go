@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// formattingDetector is a formatting abuse detector in the formatting settings
+// panel.
+type formattingDetector struct {
+	// Name is the detector name (see antispam.Detector)
+	Name string
+
+	// Label is the detector name shown in the panel
+	Label string
+
+	// Thresholds are the selectable thresholds, the first one is the default
+	Thresholds []float64
+
+	// Pretty returns an human-friendly name for the given threshold
+	Pretty func(threshold float64, bot *telegramBot, lang string) string
+}
+
+// prettyPercent returns the given threshold as percent of the message.
+func prettyPercent(threshold float64, bot *telegramBot, lang string) string {
+	return strconv.FormatFloat(threshold*100, 'f', 0, 64) + "%"
+}
+
+// formattingDetectors are the detectors in the formatting settings panel.
+var formattingDetectors = []formattingDetector{
+	{
+		Name:       "mentions",
+		Label:      "Mentions",
+		Thresholds: []float64{5.0 / antispam.MentionScale, 3.0 / antispam.MentionScale, 10.0 / antispam.MentionScale},
+		Pretty: func(threshold float64, bot *telegramBot, lang string) string {
+			return fmt.Sprintf(bot.bundle.T(lang, "more than %d"), int(threshold*antispam.MentionScale+0.5))
+		},
+	},
+	{
+		Name:       "emojis",
+		Label:      "Emojis",
+		Thresholds: []float64{0.5, 0.25, 0.75},
+		Pretty:     prettyPercent,
+	},
+	{
+		Name:       "caps",
+		Label:      "Capital letters",
+		Thresholds: []float64{0.8, 0.6, 0.95},
+		Pretty:     prettyPercent,
+	},
+	{
+		Name:       "zalgo",
+		Label:      "Zalgo text",
+		Thresholds: []float64{0.1, 0.05, 0.25},
+		Pretty:     prettyPercent,
+	},
+	{
+		Name:       "invisible",
+		Label:      "Invisible characters",
+		Thresholds: []float64{0.05, 0.01, 0.1},
+		Pretty:     prettyPercent,
+	},
+}
+
+// sendFormattingSettingsMessage sends the formatting abuse settings panel,
+// editing the given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the formatting button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendFormattingSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🎨 " + bot.bundle.T(lang, "*Formatting abuse*: mention floods, emoji walls, capital letters, zalgo text and invisible characters.\n"))
+	buf.WriteString(bot.bundle.T(lang, "For each check, the first button changes the action and the second one the threshold.\n"))
+
+	var keyboard [][]tb.InlineButton
+	for _, fd := range formattingDetectors {
+		detector := settings.Detector(fd.Name)
+		threshold := detector.Threshold
+		if threshold == 0 {
+			threshold = fd.Thresholds[0]
+		}
+
+		actionBtn := tb.InlineButton{
+			Unique: "settings_formatting_action_" + fd.Name,
+			Text:   bot.bundle.T(lang, fd.Label) + ": " + prettyActionName(detector.Action, bot, lang),
+		}
+		bot.handleAdminCallbackStateful(&actionBtn, bot.callbackFormattingSettings(func(name string) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				detector := settings.Detector(name)
+				detector.Action = nextAction(detector.Action)
+				settings.SetDetector(name, detector)
+				return settings
+			}
+		}(fd.Name)))
+
+		thresholdBtn := tb.InlineButton{
+			Unique: "settings_formatting_threshold_" + fd.Name,
+			Text:   "📏 " + fd.Pretty(threshold, bot, lang),
+		}
+		bot.handleAdminCallbackStateful(&thresholdBtn, bot.callbackFormattingSettings(func(fd formattingDetector) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				detector := settings.Detector(fd.Name)
+				current := detector.Threshold
+				if current == 0 {
+					current = fd.Thresholds[0]
+				}
+				next := 0
+				for i, t := range fd.Thresholds {
+					if t == current {
+						next = (i + 1) % len(fd.Thresholds)
+					}
+				}
+				detector.Threshold = fd.Thresholds[next]
+				settings.SetDetector(fd.Name, detector)
+				return settings
+			}
+		}(fd)))
+
+		keyboard = append(keyboard, []tb.InlineButton{actionBtn, thresholdBtn})
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_formatting_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackFormattingSettings is like callbackAntispamSettings, but it goes back
+// to the formatting abuse settings panel.
+func (bot *telegramBot) callbackFormattingSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to formatting abuse settings
+		bot.sendFormattingSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
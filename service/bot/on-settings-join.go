@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// joinActionPrefix is the key prefix of join policy actions in
+// configurableActions.
+const joinActionPrefix = "join_"
+
+// sendJoinSettingsMessage sends the join policy settings panel, editing the
+// given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the join policy button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendJoinSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("👤 " + bot.bundle.T(lang, "*Join policy*: checks on the profile of new members.\n"))
+	buf.WriteString(bot.bundle.T(lang, "Press a button to change the action.\n"))
+
+	var keyboard [][]tb.InlineButton
+	for _, ja := range configurableActions {
+		if !strings.HasPrefix(ja.Key, joinActionPrefix) {
+			continue
+		}
+		bt := tb.InlineButton{
+			Unique: "settings_join_" + ja.Key,
+			Text:   bot.bundle.T(lang, ja.Label) + ": " + prettyActionName(ja.Get(&settings), bot, lang),
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackJoinSettings(func(ja configurableAction) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				ja.Set(&settings, nextAction(ja.Get(&settings)))
+				return settings
+			}
+		}(ja)))
+		keyboard = append(keyboard, []tb.InlineButton{bt})
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_join_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackJoinSettings is like callbackAntispamSettings, but it goes back to
+// the join policy settings panel.
+func (bot *telegramBot) callbackJoinSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to join policy settings
+		bot.sendJoinSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
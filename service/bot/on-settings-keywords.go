@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// maxKeywordButtons is the maximum number of delete buttons in the keyword
+// settings panel. Other rules can be removed with /keywords command.
+const maxKeywordButtons = 20
+
+// sendKeywordSettingsMessage sends the keyword blocklist settings panel,
+// editing the given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the keywords button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendKeywordSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	rules, err := bot.db.GetKeywords(chatToConfigure.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chatToConfigure.ID).Error("Failed to get keyword blocklist")
+		return
+	}
+	detector := settings.Detector("keywords")
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("📝 " + bot.bundle.T(lang, "*Keyword* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(detector.Action, bot, lang))
+	buf.WriteString("*\n\n")
+	buf.WriteString(bot.bundle.T(lang, "Blocked keywords and regular expressions:\n"))
+	if len(rules) == 0 {
+		buf.WriteString("• " + bot.bundle.T(lang, "none") + "\n")
+	}
+	for _, rule := range rules {
+		buf.WriteString("• `" + rule.String() + "`\n")
+	}
+
+	var keyboard [][]tb.InlineButton
+
+	// Action button: each click selects the next action.
+	actionBtn := tb.InlineButton{
+		Unique: "settings_keywords_action",
+		Text:   bot.bundle.T(lang, "Action: ") + prettyActionName(detector.Action, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&actionBtn, bot.callbackKeywordSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		detector := settings.Detector("keywords")
+		detector.Action = nextAction(detector.Action)
+		settings.SetDetector("keywords", detector)
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{actionBtn})
+
+	// Add rules buttons: the next message from the user is the rule list.
+	addKeywordBtn := tb.InlineButton{
+		Unique: "settings_keywords_add",
+		Text:   "➕ " + bot.bundle.T(lang, "Keywords"),
+	}
+	bot.handleAdminCallbackStateful(&addKeywordBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		_, _ = bot.telebot.Edit(callback.Message, bot.bundle.T(lang, "Write the keywords to block, one per line. Keywords are case-insensitive."))
+		state.AddKeyword = true
+		state.AddKeywordRegexp = false
+		state.Save()
+	})
+	addRegexpBtn := tb.InlineButton{
+		Unique: "settings_keywords_add_regexp",
+		Text:   "➕ " + bot.bundle.T(lang, "Regular expressions"),
+	}
+	bot.handleAdminCallbackStateful(&addRegexpBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		_, _ = bot.telebot.Edit(callback.Message, bot.bundle.T(lang, "Write the regular expressions to block (RE2 syntax), one per line. As example:\n\n(?i)free\\s+crypto"))
+		state.AddKeywordRegexp = true
+		state.AddKeyword = false
+		state.Save()
+	})
+	keyboard = append(keyboard, []tb.InlineButton{addKeywordBtn, addRegexpBtn})
+
+	// Delete buttons, one per rule.
+	for i, rule := range rules {
+		if i == maxKeywordButtons {
+			break
+		}
+		bt := tb.InlineButton{
+			Unique: "settings_keywords_del_" + sha1string(rule.String()),
+			Text:   "❌ " + rule.String(),
+		}
+		bot.handleAdminCallbackStateful(&bt, func(rule database.KeywordRule) func(tb.Context, State) {
+			return func(ctx tb.Context, state State) {
+				if err := bot.db.RemoveKeyword(state.ChatToEdit.ID, rule); err != nil {
+					bot.logger.WithError(err).WithField("chatid", state.ChatToEdit.ID).Error("Failed to remove keyword")
+				}
+				bot.invalidateKeywordDetector(state.ChatToEdit.ID)
+				bot.callbackKeywordSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+					return settings
+				})(ctx, state)
+			}
+		}(rule))
+		keyboard = append(keyboard, []tb.InlineButton{bt})
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_keywords_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackKeywordSettings is like callbackAntispamSettings, but it goes back to
+// the keyword settings panel.
+func (bot *telegramBot) callbackKeywordSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to keyword settings
+		bot.sendKeywordSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
+
+// stateAddKeywords handles the message with the rule list when the user state
+// has AddKeyword or AddKeywordRegexp flags.
+func (bot *telegramBot) stateAddKeywords(ctx tb.Context, state State) {
+	m := ctx.Message()
+	lang := ctx.Sender().LanguageCode
+
+	// Each line is a rule. Invalid regular expressions are reported back.
+	var invalid []string
+	for _, line := range strings.Split(m.Text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		rule := database.KeywordRule{Pattern: line, Regexp: state.AddKeywordRegexp}
+		if rule.Regexp {
+			if _, err := regexp.Compile(line); err != nil {
+				invalid = append(invalid, line)
+				continue
+			}
+		}
+		err := bot.db.AddKeyword(state.ChatToEdit.ID, rule)
+		bot.invalidateKeywordDetector(state.ChatToEdit.ID)
+		if err != nil {
+			bot.logger.WithError(err).WithField("chatid", state.ChatToEdit.ID).Error("Failed to add keyword")
+			_, _ = bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+			return
+		}
+	}
+
+	// Reset keyword flags in user state.
+	state.AddKeyword = false
+	state.AddKeywordRegexp = false
+	state.Save()
+
+	// Button for opening the keyword settings again.
+	backBtn := tb.InlineButton{
+		Unique: "back_to_keywords_settings",
+		Text:   "◀ " + bot.bundle.T(lang, "Back to keyword settings"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendKeywordSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	msg := bot.bundle.T(lang, "Keywords saved")
+	if len(invalid) > 0 {
+		msg += "\n\n" + bot.bundle.T(lang, "These lines are not valid regular expressions and have been ignored:") + "\n" + strings.Join(invalid, "\n")
+	}
+	_, _ = bot.telebot.Send(m.Chat, msg, &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{{backBtn}},
+	})
+}
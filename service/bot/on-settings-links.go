@@ -0,0 +1,236 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// sendLinkSettingsMessage sends the link settings panel, editing the given
+// message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the links button, inside
+// the antispam settings panel.
+func (bot *telegramBot) sendLinkSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	detector := settings.Detector("links")
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🔗 " + bot.bundle.T(lang, "*Link* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(detector.Action, bot, lang))
+	buf.WriteString("*\n")
+	if settings.LinkBlockAll {
+		buf.WriteString(bot.bundle.T(lang, "All links from non-admins are blocked, except allowed domains\n"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "Only links to denied domains are blocked\n"))
+	}
+	buf.WriteString(bot.bundle.T(lang, "\nAllowed domains:\n"))
+	writeDomainList(&buf, settings.LinkAllow, bot.bundle.T(lang, "none"))
+	buf.WriteString(bot.bundle.T(lang, "\nDenied domains:\n"))
+	writeDomainList(&buf, settings.LinkDeny, bot.bundle.T(lang, "none"))
+
+	// Action button: each click selects the next action.
+	actionBtn := tb.InlineButton{
+		Unique: "settings_links_action",
+		Text:   bot.bundle.T(lang, "Action: ") + prettyActionName(detector.Action, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&actionBtn, bot.callbackLinkSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		detector := settings.Detector("links")
+		detector.Action = nextAction(detector.Action)
+		settings.SetDetector("links", detector)
+		return settings
+	}))
+
+	// Block all links button.
+	blockAllText := "✅ " + bot.bundle.T(lang, "Block all links")
+	if settings.LinkBlockAll {
+		blockAllText = "❌ " + bot.bundle.T(lang, "Block only denied links")
+	}
+	blockAllBtn := tb.InlineButton{
+		Unique: "settings_links_block_all",
+		Text:   blockAllText,
+	}
+	bot.handleAdminCallbackStateful(&blockAllBtn, bot.callbackLinkSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.LinkBlockAll = !settings.LinkBlockAll
+		return settings
+	}))
+
+	// Add domains buttons: the next message from the user is the domain list.
+	addAllowBtn := tb.InlineButton{
+		Unique: "settings_links_add_allow",
+		Text:   "➕ " + bot.bundle.T(lang, "Allow domains"),
+	}
+	bot.handleAdminCallbackStateful(&addAllowBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		_, _ = bot.telebot.Edit(callback.Message, bot.bundle.T(lang, "Write the domains to allow, one per line. As example:\n\nuniroma1.it\nsapienzahub.it"))
+		state.AddLinkAllow = true
+		state.AddLinkDeny = false
+		state.Save()
+	})
+	addDenyBtn := tb.InlineButton{
+		Unique: "settings_links_add_deny",
+		Text:   "➕ " + bot.bundle.T(lang, "Deny domains"),
+	}
+	bot.handleAdminCallbackStateful(&addDenyBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		_, _ = bot.telebot.Edit(callback.Message, bot.bundle.T(lang, "Write the domains to deny, one per line. As example:\n\nbit.ly\nt.me"))
+		state.AddLinkDeny = true
+		state.AddLinkAllow = false
+		state.Save()
+	})
+
+	// Clear lists buttons.
+	clearAllowBtn := tb.InlineButton{
+		Unique: "settings_links_clear_allow",
+		Text:   "🗑 " + bot.bundle.T(lang, "Clear allowed"),
+	}
+	bot.handleAdminCallbackStateful(&clearAllowBtn, bot.callbackLinkSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.LinkAllow = nil
+		return settings
+	}))
+	clearDenyBtn := tb.InlineButton{
+		Unique: "settings_links_clear_deny",
+		Text:   "🗑 " + bot.bundle.T(lang, "Clear denied"),
+	}
+	bot.handleAdminCallbackStateful(&clearDenyBtn, bot.callbackLinkSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.LinkDeny = nil
+		return settings
+	}))
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_links_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode: tb.ModeMarkdown,
+		ReplyMarkup: &tb.ReplyMarkup{
+			InlineKeyboard: [][]tb.InlineButton{
+				{actionBtn},
+				{blockAllBtn},
+				{addAllowBtn, addDenyBtn},
+				{clearAllowBtn, clearDenyBtn},
+				{backBtn},
+			},
+		},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// writeDomainList writes the given domains in buf, one per line. If the list is
+// empty, it writes the given empty text.
+func writeDomainList(buf *strings.Builder, domains []string, empty string) {
+	if len(domains) == 0 {
+		buf.WriteString("• " + empty + "\n")
+		return
+	}
+	for _, domain := range domains {
+		buf.WriteString("• `" + domain + "`\n")
+	}
+}
+
+// callbackLinkSettings is like callbackAntispamSettings, but it goes back to
+// the link settings panel.
+func (bot *telegramBot) callbackLinkSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to link settings
+		bot.sendLinkSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
+
+// stateAddLinkDomains handles the message with the domain list when the user
+// state has AddLinkAllow or AddLinkDeny flags.
+func (bot *telegramBot) stateAddLinkDomains(ctx tb.Context, state State) {
+	m := ctx.Message()
+	lang := ctx.Sender().LanguageCode
+
+	settings, err := bot.getChatSettings(state.ChatToEdit)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", state.ChatToEdit.ID).Warn("Failed to get chat settings")
+		return
+	}
+
+	// Each line is a domain, or a URL.
+	var invalid []string
+	for _, line := range strings.Split(m.Text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		domain := antispam.URLHost(line)
+		if domain == "" || !strings.Contains(domain, ".") {
+			invalid = append(invalid, line)
+			continue
+		}
+		if state.AddLinkAllow && !containsString(settings.LinkAllow, domain) {
+			settings.LinkAllow = append(settings.LinkAllow, domain)
+		} else if state.AddLinkDeny && !containsString(settings.LinkDeny, domain) {
+			settings.LinkDeny = append(settings.LinkDeny, domain)
+		}
+	}
+
+	// Reset link flags in user state, even on errors.
+	state.AddLinkAllow = false
+	state.AddLinkDeny = false
+	state.Save()
+
+	err = bot.db.SetChatSettings(state.ChatToEdit.ID, settings.ChatSettings)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", state.ChatToEdit.ID).Warn("Failed to save chat settings")
+		_, _ = bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+		return
+	}
+
+	// Button for opening the link settings again.
+	backBtn := tb.InlineButton{
+		Unique: "back_to_links_settings",
+		Text:   "◀ " + bot.bundle.T(lang, "Back to link settings"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendLinkSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+
+	msg := bot.bundle.T(lang, "Domains saved")
+	if len(invalid) > 0 {
+		msg += "\n\n" + bot.bundle.T(lang, "These lines are not valid domains and have been ignored:") + "\n" + strings.Join(invalid, "\n")
+	}
+	_, _ = bot.telebot.Send(m.Chat, msg, &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{{backBtn}},
+	})
+}
@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// Selectable values in the probation settings panel. Zero means "no limit".
+var (
+	probationDurationOptions = []uint{0, 3600, 6 * 3600, 24 * 3600, 72 * 3600}
+	probationMessagesOptions = []uint{0, 3, 5, 10}
+)
+
+// defaultProbationSettings are the limits used when the probation is enabled
+// for the first time.
+var defaultProbationSettings = database.ProbationSettings{
+	Enabled:  true,
+	Duration: 24 * 3600,
+	Messages: 5,
+}
+
+// sendProbationSettingsMessage sends the new members probation settings panel,
+// editing the given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the probation button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendProbationSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🐣 " + bot.bundle.T(lang, "*Probation* for new members:\n"))
+	buf.WriteString(bot.bundle.T(lang, "New members cannot send media, stickers, links and forwards until the probation ends.\n\n"))
+	if settings.Probation.Enabled {
+		buf.WriteString(bot.bundle.T(lang, "Status: *enabled*\n"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "Status: *disabled*\n"))
+	}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Duration: *%s*\n"), prettyDuration(settings.Probation.Duration, bot, lang)))
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Clean messages: *%s*\n"), prettyLimit(settings.Probation.Messages, bot, lang)))
+
+	var keyboard [][]tb.InlineButton
+
+	// Enable/disable button.
+	enableBtnText := "❌ " + bot.bundle.T(lang, "Probation disabled")
+	if settings.Probation.Enabled {
+		enableBtnText = "✅ " + bot.bundle.T(lang, "Probation enabled")
+	}
+	enableBtn := tb.InlineButton{
+		Unique: "settings_probation_enable",
+		Text:   enableBtnText,
+	}
+	bot.handleAdminCallbackStateful(&enableBtn, bot.callbackProbationSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		if settings.Probation == (database.ProbationSettings{}) {
+			settings.Probation = defaultProbationSettings
+		} else {
+			settings.Probation.Enabled = !settings.Probation.Enabled
+		}
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{enableBtn})
+
+	// Duration buttons.
+	var row []tb.InlineButton
+	for _, option := range probationDurationOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_probation_duration_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "⏱ " + prettyDuration(option, bot, lang),
+		}
+		if option == settings.Probation.Duration {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackProbationSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Probation.Duration = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Clean messages buttons.
+	row = nil
+	for _, option := range probationMessagesOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_probation_messages_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "✉️ " + prettyLimit(option, bot, lang),
+		}
+		if option == settings.Probation.Messages {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackProbationSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Probation.Messages = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_probation_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackProbationSettings is like callbackAntispamSettings, but it goes back
+// to the probation settings panel.
+func (bot *telegramBot) callbackProbationSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		if newsettings.Probation.Duration == 0 && newsettings.Probation.Messages == 0 {
+			// The probation would never end.
+			_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+				Text:      bot.bundle.T(callback.Sender.LanguageCode, "The probation needs a duration or a number of clean messages"),
+				ShowAlert: true,
+			})
+			return
+		}
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to probation settings
+		bot.sendProbationSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+
+		// Members in probation are not checked anymore, so the restrictions
+		// would never be lifted.
+		if settings.Probation.Enabled && !newsettings.Probation.Enabled {
+			bot.liftChatProbations(state.ChatToEdit, newsettings, "Probation disabled")
+		}
+	}
+}
+
+// prettyDuration returns an human-friendly name for the given duration in
+// seconds, where zero means "no limit".
+func prettyDuration(seconds uint, bot *telegramBot, lang string) string {
+	if seconds == 0 {
+		return bot.bundle.T(lang, "no limit")
+	}
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + bot.bundle.T(lang, "d")
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + bot.bundle.T(lang, "h")
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	default:
+		return strconv.FormatUint(uint64(seconds), 10) + "s"
+	}
+}
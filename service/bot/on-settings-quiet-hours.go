@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// quietHoursTimeZones are the selectable time zones for quiet hours, where the
+// empty string is UTC.
+var quietHoursTimeZones = []string{"", "Europe/Rome", "Europe/London", "America/New_York", "Asia/Tokyo"}
+
+// quietHoursDays are the short names of the weekdays, starting from Monday.
+var quietHoursDays = []struct {
+	Day  time.Weekday
+	Name string
+}{
+	{time.Monday, "Mon"},
+	{time.Tuesday, "Tue"},
+	{time.Wednesday, "Wed"},
+	{time.Thursday, "Thu"},
+	{time.Friday, "Fri"},
+	{time.Saturday, "Sat"},
+	{time.Sunday, "Sun"},
+}
+
+// sendQuietHoursSettingsMessage sends the quiet hours settings panel, editing
+// the given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the quiet hours button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendQuietHoursSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	q := settings.QuietHours
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🌙 " + bot.bundle.T(lang, "*Quiet hours*: the chat permissions are restricted on a weekly schedule.\n\n"))
+	if q.Enabled {
+		buf.WriteString(bot.bundle.T(lang, "Status: *enabled*\n"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "Status: *disabled*\n"))
+	}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "From *%02d:00* to *%02d:00* (%s)\n"), q.Start, q.End, quietHoursLocation(q)))
+
+	var keyboard [][]tb.InlineButton
+
+	// Enable/disable button.
+	enableBtnText := "❌ " + bot.bundle.T(lang, "Quiet hours disabled")
+	if q.Enabled {
+		enableBtnText = "✅ " + bot.bundle.T(lang, "Quiet hours enabled")
+	}
+	enableBtn := tb.InlineButton{
+		Unique: "settings_quiet_enable",
+		Text:   enableBtnText,
+	}
+	bot.handleAdminCallbackStateful(&enableBtn, bot.callbackQuietHoursSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.QuietHours.Enabled = !settings.QuietHours.Enabled
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{enableBtn})
+
+	// Weekday buttons.
+	var row []tb.InlineButton
+	for _, d := range quietHoursDays {
+		bt := tb.InlineButton{
+			Unique: "settings_quiet_day_" + strconv.Itoa(int(d.Day)),
+			Text:   bot.bundle.T(lang, d.Name),
+		}
+		if q.Days&(1<<uint(d.Day)) != 0 {
+			bt.Text = "✅" + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackQuietHoursSettings(func(day time.Weekday) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.QuietHours.Days ^= 1 << uint(day)
+				return settings
+			}
+		}(d.Day)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Start and end hour buttons.
+	hourButton := func(unique string, text string, fn func(settings *chatSettings) *uint, delta uint) tb.InlineButton {
+		bt := tb.InlineButton{Unique: unique, Text: text}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackQuietHoursSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+			hour := fn(&settings)
+			*hour = (*hour + delta) % 24
+			return settings
+		}))
+		return bt
+	}
+	start := func(settings *chatSettings) *uint { return &settings.QuietHours.Start }
+	end := func(settings *chatSettings) *uint { return &settings.QuietHours.End }
+	keyboard = append(keyboard, []tb.InlineButton{
+		hourButton("settings_quiet_start_dec", "🌙 -1h", start, 23),
+		hourButton("settings_quiet_start_inc", "🌙 +1h", start, 1),
+		hourButton("settings_quiet_end_dec", "☀️ -1h", end, 23),
+		hourButton("settings_quiet_end_inc", "☀️ +1h", end, 1),
+	})
+
+	// Profile button.
+	profileBtn := tb.InlineButton{
+		Unique: "settings_quiet_profile",
+		Text:   "🔇 " + prettyQuietHoursProfile(q.Profile, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&profileBtn, bot.callbackQuietHoursSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		if settings.QuietHours.Profile == database.QuietReadOnly {
+			settings.QuietHours.Profile = database.QuietTextOnly
+		} else {
+			settings.QuietHours.Profile = database.QuietReadOnly
+		}
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{profileBtn})
+
+	// Time zone button.
+	timeZoneBtn := tb.InlineButton{
+		Unique: "settings_quiet_timezone",
+		Text:   "🌍 " + quietHoursLocation(q).String(),
+	}
+	bot.handleAdminCallbackStateful(&timeZoneBtn, bot.callbackQuietHoursSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		next := 0
+		for i, tz := range quietHoursTimeZones {
+			if tz == settings.QuietHours.TimeZone {
+				next = (i + 1) % len(quietHoursTimeZones)
+			}
+		}
+		settings.QuietHours.TimeZone = quietHoursTimeZones[next]
+		return settings
+	}))
+
+	// Announce button.
+	announceBtnText := "🔕 " + bot.bundle.T(lang, "Do not announce")
+	if q.Announce {
+		announceBtnText = "📢 " + bot.bundle.T(lang, "Announce in chat")
+	}
+	announceBtn := tb.InlineButton{
+		Unique: "settings_quiet_announce",
+		Text:   announceBtnText,
+	}
+	bot.handleAdminCallbackStateful(&announceBtn, bot.callbackQuietHoursSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		settings.QuietHours.Announce = !settings.QuietHours.Announce
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{timeZoneBtn, announceBtn})
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_quiet_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackQuietHoursSettings is like callbackAntispamSettings, but it goes back
+// to the quiet hours settings panel.
+func (bot *telegramBot) callbackQuietHoursSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to quiet hours settings
+		bot.sendQuietHoursSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+
+		// Apply the new schedule, and move the next boundary.
+		if err := bot.updateQuietHours(state.ChatToEdit, newsettings); err != nil {
+			bot.logger.WithError(err).WithField("chatid", state.ChatToEdit.ID).Error("Failed to update quiet hours")
+		}
+	}
+}
+
+// prettyQuietHoursProfile returns an human-friendly name for the given quiet
+// hours permission profile.
+func prettyQuietHoursProfile(profile int, bot *telegramBot, lang string) string {
+	if profile == database.QuietReadOnly {
+		return bot.bundle.T(lang, "Read only")
+	}
+	return bot.bundle.T(lang, "Text only")
+}
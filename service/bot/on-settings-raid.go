@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+var (
+	// raidJoinsOptions are the selectable join thresholds, where zero disables
+	// raid detection.
+	raidJoinsOptions = []uint{0, 10, 20, 50}
+
+	// raidWindowOptions are the selectable raid detection windows, in seconds.
+	raidWindowOptions = []uint{30, 60, 300}
+
+	// lockdownOptions are the selectable lockdown durations, in seconds.
+	lockdownOptions = []uint{15 * 60, 30 * 60, 3600, 6 * 3600}
+)
+
+// sendRaidSettingsMessage sends the raid detection settings panel, editing the
+// given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the raid detection button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendRaidSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	window := uint(defaultRaidWindow.Seconds())
+	if settings.Raid.Window > 0 {
+		window = settings.Raid.Window
+	}
+	lockdown := uint(lockdownDuration(settings).Seconds())
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🚨 " + bot.bundle.T(lang, "*Raid detection*: when too many users join in a short time, the chat goes in lockdown. During the lockdown nobody can send messages and new members are muted.\n\n"))
+	if settings.Raid.Joins == 0 {
+		buf.WriteString(bot.bundle.T(lang, "Status: *disabled*\n"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "Status: *enabled*\n"))
+		buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Lockdown after more than %d joins in %s, for %s.\n"), settings.Raid.Joins, prettyDuration(window, bot, lang), prettyDuration(lockdown, bot, lang)))
+	}
+
+	var keyboard [][]tb.InlineButton
+
+	// Join threshold buttons.
+	var row []tb.InlineButton
+	for _, option := range raidJoinsOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_raid_joins_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "👥 " + strconv.FormatUint(uint64(option), 10),
+		}
+		if option == 0 {
+			bt.Text = "❌ " + bot.bundle.T(lang, "Disabled")
+		}
+		if option == settings.Raid.Joins {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackRaidSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Raid.Joins = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Window buttons.
+	row = nil
+	for _, option := range raidWindowOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_raid_window_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "⏱ " + prettyDuration(option, bot, lang),
+		}
+		if option == window {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackRaidSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Raid.Window = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Lockdown duration buttons.
+	row = nil
+	for _, option := range lockdownOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_raid_lockdown_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "🔒 " + prettyDuration(option, bot, lang),
+		}
+		if option == lockdown {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackRaidSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Raid.Lockdown = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_raid_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackRaidSettings is like callbackAntispamSettings, but it goes back to
+// the raid detection settings panel.
+func (bot *telegramBot) callbackRaidSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to raid detection settings
+		bot.sendRaidSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
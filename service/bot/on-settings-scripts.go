@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// selectableScripts is the list of Unicode scripts (see unicode.Scripts) that
+// chat admins can block using the script settings panel.
+var selectableScripts = []string{
+	"Arabic", "Armenian", "Bengali",
+	"Cyrillic", "Devanagari", "Ethiopic",
+	"Georgian", "Greek", "Gujarati",
+	"Han", "Hangul", "Hebrew",
+	"Hiragana", "Katakana", "Khmer",
+	"Lao", "Myanmar", "Sinhala",
+	"Tamil", "Telugu", "Thai",
+}
+
+// selectableThresholds is the list of thresholds that chat admins can choose for
+// percent-based detectors.
+var selectableThresholds = []float64{0.01, 0.05, 0.1, 0.25, 0.5}
+
+// sendScriptSettingsMessage sends the script settings panel, editing the given
+// message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the scripts button, inside
+// the antispam settings panel.
+func (bot *telegramBot) sendScriptSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	detector := settings.Detector("script")
+	threshold := detector.Threshold
+	if threshold == 0 {
+		threshold = 0.05
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🔤 " + bot.bundle.T(lang, "*Script* blocker:\n"))
+	buf.WriteString(bot.bundle.T(lang, "On message: *"))
+	buf.WriteString(prettyActionName(detector.Action, bot, lang))
+	buf.WriteString("*\n")
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Threshold: *%.0f%%* of the message\n"), threshold*100))
+	buf.WriteString(bot.bundle.T(lang, "Blocked scripts: *"))
+	if len(settings.Scripts) == 0 {
+		buf.WriteString(bot.bundle.T(lang, "none"))
+	} else {
+		buf.WriteString(strings.Join(settings.Scripts, ", "))
+	}
+	buf.WriteString("*\n")
+
+	var keyboard [][]tb.InlineButton
+
+	// Action button: each click selects the next action.
+	actionBtn := tb.InlineButton{
+		Unique: "settings_script_action",
+		Text:   bot.bundle.T(lang, "Action: ") + prettyActionName(detector.Action, bot, lang),
+	}
+	bot.handleAdminCallbackStateful(&actionBtn, bot.callbackScriptSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		detector := settings.Detector("script")
+		detector.Action = nextAction(detector.Action)
+		settings.SetDetector("script", detector)
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{actionBtn})
+
+	// Threshold buttons.
+	var row []tb.InlineButton
+	for _, t := range selectableThresholds {
+		text := strconv.FormatFloat(t*100, 'f', 0, 64) + "%"
+		if t == threshold {
+			text = "✅ " + text
+		}
+		bt := tb.InlineButton{
+			Unique: "settings_script_threshold_" + strconv.FormatFloat(t*100, 'f', 0, 64),
+			Text:   text,
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackScriptSettings(func(t float64) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				detector := settings.Detector("script")
+				detector.Threshold = t
+				settings.SetDetector("script", detector)
+				return settings
+			}
+		}(t)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Script buttons, three per row.
+	row = nil
+	for _, script := range selectableScripts {
+		text := script
+		if containsString(settings.Scripts, script) {
+			text = "🚫 " + script
+		}
+		bt := tb.InlineButton{
+			Unique: "settings_script_toggle_" + script,
+			Text:   text,
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackScriptSettings(func(script string) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				if containsString(settings.Scripts, script) {
+					settings.Scripts = removeString(settings.Scripts, script)
+				} else {
+					settings.Scripts = append(settings.Scripts, script)
+				}
+				return settings
+			}
+		}(script)))
+		row = append(row, bt)
+		if len(row) == 3 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_script_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackScriptSettings is like callbackAntispamSettings, but it goes back to
+// the script settings panel.
+func (bot *telegramBot) callbackScriptSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to script settings
+		bot.sendScriptSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
+
+// nextAction returns the action that follows the given one, cycling over all
+// actions. It is used by buttons that select an action with multiple clicks.
+func nextAction(action database.BotAction) database.BotAction {
+	switch action.Action {
+	case database.ActionNone:
+		action.Action = database.ActionDeleteMsg
+	case database.ActionDeleteMsg:
+		action.Action = database.ActionMute
+	case database.ActionMute:
+		action.Action = database.ActionKick
+	case database.ActionKick:
+		action.Action = database.ActionBan
+	default:
+		action.Action = database.ActionNone
+	}
+	return action
+}
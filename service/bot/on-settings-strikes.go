@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// strikeDecayOptions are the selectable strike decays, in seconds. Zero means
+// "no limit" (strikes are never forgotten).
+var strikeDecayOptions = []uint{0, 24 * 3600, 7 * 24 * 3600, 30 * 24 * 3600}
+
+// sendStrikesSettingsMessage sends the strikes settings panel, editing the
+// given message and localizing the text with the given language.
+//
+// This panel can be accessed when the user clicks on the strikes button,
+// inside the antispam settings panel.
+func (bot *telegramBot) sendStrikesSettingsMessage(m *tb.Message, lang string, chatToConfigure *tb.Chat, settings chatSettings) {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Bot settings for chat %s (%d)\n\n"), chatToConfigure.Title, chatToConfigure.ID))
+	buf.WriteString("🪜 " + bot.bundle.T(lang, "*Strikes* for repeated violations:\n"))
+	buf.WriteString(bot.bundle.T(lang, "When enabled, each violation adds a strike to the user, and the action depends on the number of strikes.\n\n"))
+	if settings.Strikes.Enabled {
+		buf.WriteString(bot.bundle.T(lang, "Status: *enabled*\n"))
+	} else {
+		buf.WriteString(bot.bundle.T(lang, "Status: *disabled*\n"))
+	}
+	buf.WriteString(fmt.Sprintf(bot.bundle.T(lang, "Strikes expire after: *%s*\n"), prettyDuration(settings.Strikes.Decay, bot, lang)))
+	for i, action := range strikeLadder(settings) {
+		buf.WriteString(fmt.Sprintf("%d. %s\n", i+1, prettyStrikeAction(action, bot, lang)))
+	}
+
+	var keyboard [][]tb.InlineButton
+
+	// Enable/disable button.
+	enableBtnText := "❌ " + bot.bundle.T(lang, "Strikes disabled")
+	if settings.Strikes.Enabled {
+		enableBtnText = "✅ " + bot.bundle.T(lang, "Strikes enabled")
+	}
+	enableBtn := tb.InlineButton{
+		Unique: "settings_strikes_enable",
+		Text:   enableBtnText,
+	}
+	bot.handleAdminCallbackStateful(&enableBtn, bot.callbackStrikesSettings(func(ctx tb.Context, settings chatSettings) chatSettings {
+		if !settings.Strikes.Enabled && len(settings.Strikes.Ladder) == 0 {
+			settings.Strikes.Decay = defaultStrikeDecay
+			settings.Strikes.Ladder = strikeLadders[0]
+		}
+		settings.Strikes.Enabled = !settings.Strikes.Enabled
+		return settings
+	}))
+	keyboard = append(keyboard, []tb.InlineButton{enableBtn})
+
+	// Decay buttons.
+	var row []tb.InlineButton
+	for _, option := range strikeDecayOptions {
+		bt := tb.InlineButton{
+			Unique: "settings_strikes_decay_" + strconv.FormatUint(uint64(option), 10),
+			Text:   "⏱ " + prettyDuration(option, bot, lang),
+		}
+		if option == settings.Strikes.Decay {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackStrikesSettings(func(option uint) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Strikes.Decay = option
+				return settings
+			}
+		}(option)))
+		row = append(row, bt)
+	}
+	keyboard = append(keyboard, row)
+
+	// Ladder buttons, one per row.
+	current := strikeLadder(settings)
+	for i, ladder := range strikeLadders {
+		var steps []string
+		for _, action := range ladder {
+			steps = append(steps, prettyStrikeAction(action, bot, lang))
+		}
+		bt := tb.InlineButton{
+			Unique: "settings_strikes_ladder_" + strconv.Itoa(i),
+			Text:   strings.Join(steps, " → "),
+		}
+		if equalLadders(ladder, current) {
+			bt.Text = "✅ " + bt.Text
+		}
+		bot.handleAdminCallbackStateful(&bt, bot.callbackStrikesSettings(func(ladder []database.BotAction) func(tb.Context, chatSettings) chatSettings {
+			return func(ctx tb.Context, settings chatSettings) chatSettings {
+				settings.Strikes.Ladder = ladder
+				return settings
+			}
+		}(ladder)))
+		keyboard = append(keyboard, []tb.InlineButton{bt})
+	}
+
+	// Back to antispam settings.
+	backBtn := tb.InlineButton{
+		Unique: "settings_strikes_back",
+		Text:   "◀ " + bot.bundle.T(lang, "Back"),
+	}
+	bot.handleAdminCallbackStateful(&backBtn, func(ctx tb.Context, state State) {
+		callback := ctx.Callback()
+		_ = bot.telebot.Respond(callback)
+
+		settings, _ := bot.getChatSettings(state.ChatToEdit)
+		bot.sendAntispamSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, settings)
+	})
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+
+	sendOpts := &tb.SendOptions{
+		ParseMode:             tb.ModeMarkdown,
+		ReplyMarkup:           &tb.ReplyMarkup{InlineKeyboard: keyboard},
+		DisableWebPagePreview: true,
+	}
+	_, _ = bot.telebot.Edit(m, buf.String(), sendOpts)
+}
+
+// callbackStrikesSettings is like callbackAntispamSettings, but it goes back
+// to the strikes settings panel.
+func (bot *telegramBot) callbackStrikesSettings(fn func(tb.Context, chatSettings) chatSettings) func(tb.Context, State) {
+	return func(ctx tb.Context, state State) {
+		settings, err := bot.getChatSettings(state.ChatToEdit)
+		if err != nil {
+			bot.logger.WithError(err).Error("Cannot get chat settings")
+			return
+		}
+
+		// Execute callback
+		callback := ctx.Callback()
+		newsettings := fn(ctx, settings)
+		_ = bot.db.SetChatSettings(state.ChatToEdit.ID, newsettings.ChatSettings)
+		_ = bot.telebot.Respond(callback, &tb.CallbackResponse{
+			Text:      "Ok",
+			ShowAlert: false,
+		})
+
+		// Back to strikes settings
+		bot.sendStrikesSettingsMessage(callback.Message, callback.Sender.LanguageCode, state.ChatToEdit, newsettings)
+	}
+}
+
+// prettyStrikeAction returns an human-friendly name for the given step of a
+// strike ladder, including the duration of mutes and bans.
+func prettyStrikeAction(action database.BotAction, bot *telegramBot, lang string) string {
+	name := prettyActionName(action, bot, lang)
+	if action.Action == database.ActionMute || action.Action == database.ActionBan {
+		name += " " + prettyActionDuration(action.Duration, bot, lang)
+	}
+	return name
+}
+
+// equalLadders returns true if the given strike ladders have the same actions.
+func equalLadders(a []database.BotAction, b []database.BotAction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// onSpam is fired on /spam command. It works only in groups, if the command is
+// given as a reply for another message: the message is used to train the spam
+// classifier as spam, and then it is deleted.
+func (bot *telegramBot) onSpam(ctx tb.Context, settings chatSettings) {
+	bot.onTrainClassifier(ctx, settings, true)
+}
+
+// onHam is fired on /ham command. It is like onSpam, but the message is used to
+// train the spam classifier as ham (not spam), and it is not deleted. If the
+// same message was previously reported as spam, the report is reverted.
+func (bot *telegramBot) onHam(ctx tb.Context, settings chatSettings) {
+	bot.onTrainClassifier(ctx, settings, false)
+}
+
+// onTrainClassifier trains the spam classifier with the message cited by the
+// command message.
+func (bot *telegramBot) onTrainClassifier(ctx tb.Context, settings chatSettings, spam bool) {
+	m := ctx.Message()
+	if m == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+		return
+	}
+
+	defer func() {
+		if err := ctx.Delete(); err != nil {
+			bot.logger.WithError(err).Error("Failed to delete message")
+		}
+	}()
+
+	if m.Private() || !m.IsReply() {
+		return
+	}
+	if spam {
+		bot.botCommandsRequestsTotal.WithLabelValues("spam").Inc()
+	} else {
+		bot.botCommandsRequestsTotal.WithLabelValues("ham").Inc()
+	}
+
+	lang := ctx.Sender().LanguageCode
+	text := strings.TrimSpace(m.ReplyTo.Text + "\n" + m.ReplyTo.Caption)
+	if err := bot.classifier.Learn(text, spam); err != nil {
+		bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to train the spam classifier")
+		msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+		bot.setMessageExpiry(msg, 10*time.Second)
+		return
+	}
+
+	if spam {
+		bot.deleteMessage(m.ReplyTo, settings, "Reported as spam by an admin")
+		return
+	}
+	msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Thanks, I will remember that this message is not spam"))
+	bot.setMessageExpiry(msg, 10*time.Second)
+}
@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"time"
 
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
 	"github.com/sirupsen/logrus"
 	tb "gopkg.in/telebot.v3"
 )
 
 // onTerminate terminates the user that the reply /terminate command refers to.
 //
-// It first warn the user, then starts a contdown of 60 seconds and there is no
+// It first warn the user, then schedules the ban in 60 seconds and there is no
 // way to stop the timer.
 func (bot *telegramBot) onTerminate(ctx tb.Context, settings chatSettings) {
 	bot.botCommandsRequestsTotal.WithLabelValues("terminate").Inc()
@@ -56,15 +58,10 @@ func (bot *telegramBot) onTerminate(ctx tb.Context, settings chatSettings) {
 		_, _ = bot.telebot.Reply(m.ReplyTo, fmt.Sprintf("🚨 %s %s "+bot.bundle.T(lang, "You will be terminated in 60 seconds, there will be no further warnings"), m.ReplyTo.Sender.FirstName, m.ReplyTo.Sender.LastName))
 	}
 
-	go func() {
-		time.Sleep(60 * time.Second)
-
-		member, err := bot.telebot.ChatMemberOf(m.Chat, m.ReplyTo.Sender)
-		if err != nil {
-			bot.logger.WithError(err).WithField("userid", m.ReplyTo.ID).Error("Failed to ban user")
-			return
-		}
-
-		_ = bot.telebot.Ban(m.Chat, member)
-	}()
+	bot.scheduleJob(database.Job{
+		Kind:   jobBan,
+		ChatID: m.Chat.ID,
+		UserID: m.ReplyTo.Sender.ID,
+		Reason: "Terminated by an admin",
+	}, 60*time.Second)
 }
@@ -1,6 +1,8 @@
 package bot
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	tb "gopkg.in/telebot.v3"
 )
@@ -74,29 +76,35 @@ func (bot *telegramBot) onUserJoined(ctx tb.Context, settings chatSettings) {
 		return
 	}
 
+	// Record the join time, for policies on recent members.
+	if err := bot.db.SetMemberJoined(m.Chat.ID, m.UserJoined.ID, time.Now()); err != nil {
+		bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to record member join time")
+	}
+
 	// Check if the user that's joining is g-lined. If so, ban them and delete
 	// the join service message.
 	if banned, err := bot.db.IsUserBanned(m.Sender.ID); err == nil && banned {
-		bot.banUser(m.Chat, m.Sender, settings, "user g-lined")
+		bot.banUser(m.Chat, m.Sender, settings, 0, "user g-lined")
 		bot.deleteMessage(m, settings, "user g-lined")
 		return
 	}
 
-	// Check if the user that's joining is CAS banned. If so, do the proper
-	// action.
-	if bot.cas != nil && bot.cas.IsBanned(m.Sender.ID) {
-		bot.casDatabaseMatch.Inc()
-		bot.performAction(m, m.Sender, settings, settings.OnBlacklistCAS, "CAS banned")
+	// Check if the user that's joining is in a ban list (like CAS). If so, do
+	// the proper action. Lists are checked on demand too, as the user may be
+	// banned after the last update of the lists.
+	if bot.banListFilter(m, m.UserJoined, settings, true) {
 		return
 	}
 
-	// Check for spam items in user names.
-	textvalues := []string{
-		m.UserJoined.Username,
-		m.UserJoined.FirstName,
-		m.UserJoined.LastName,
+	// Check the profile of the user against the join policy. Then, check
+	// whether the chat is being raided: during a lockdown, new members are
+	// muted until the end.
+	if !bot.joinFilter(m, m.UserJoined, settings) && !bot.raidFilter(m, m.UserJoined, settings) {
+		// New members must pass the CAPTCHA before the probation.
+		if !bot.startCaptcha(m.Chat, m.UserJoined, settings) {
+			bot.startProbation(m.Chat, m.UserJoined, settings)
+		}
 	}
-	bot.spamFilter(m, settings, textvalues)
 
 	// If the owner wants to delete all join messages, do so.
 	if settings.OnJoinDelete {
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// startProbation puts the given user in probation in the given chat, if the
+// probation is enabled in chat settings. The user cannot send media, stickers,
+// GIFs and link previews until the probation ends: links and forwards are
+// deleted by probationFilter, as Telegram has no permission for them.
+//
+// It has no effect on chat admins.
+func (bot *telegramBot) startProbation(chat *tb.Chat, user *tb.User, settings chatSettings) {
+	if !settings.Probation.Enabled || settings.ChatAdmins.IsAdmin(user) {
+		return
+	}
+	logfields := logrus.Fields{
+		"userid": user.ID,
+		"chatid": chat.ID,
+	}
+
+	member, err := bot.telebot.ChatMemberOf(chat, user)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("probation: failed to get member object for user")
+		return
+	}
+	member.CanSendMessages = true
+	member.CanSendMedia = false
+	member.CanSendPolls = false
+	member.CanSendOther = false
+	member.CanAddPreviews = false
+	if err := bot.telebot.Restrict(chat, member); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("probation: failed to save member restriction")
+		return
+	}
+
+	var until time.Time
+	duration := time.Duration(settings.Probation.Duration) * time.Second
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	if err := bot.db.AddProbation(chat.ID, user.ID, until); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("probation: failed to save probation")
+		return
+	}
+	if duration > 0 {
+		bot.scheduleJob(database.Job{Kind: jobLiftProbation, ChatID: chat.ID, UserID: user.ID}, duration)
+	}
+
+	bot.logger.WithFields(logfields).Info("probation start")
+	settings.Log("probation start", bot.telebot.Me, user, "New member")
+}
+
+// probationFilter checks the given message if its sender is in probation:
+// messages with links or forwards are deleted. It returns true if the message
+// has been deleted.
+func (bot *telegramBot) probationFilter(m *tb.Message, settings chatSettings, textvalues []string) bool {
+	if !settings.Probation.Enabled {
+		return false
+	}
+	inProbation, err := bot.db.IsInProbation(m.Chat.ID, m.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to check probation")
+		return false
+	} else if !inProbation {
+		return false
+	}
+
+	if m.IsForwarded() {
+		bot.deleteMessage(m, settings, "Probation: forwards are not allowed")
+		return true
+	}
+	for _, text := range textvalues {
+		if len(antispam.ExtractURLs(text)) > 0 {
+			bot.deleteMessage(m, settings, "Probation: links are not allowed")
+			return true
+		}
+	}
+	return false
+}
+
+// countProbationMessage counts a clean message of a user in probation. The
+// probation ends when the user reaches the number of messages in settings.
+func (bot *telegramBot) countProbationMessage(m *tb.Message, settings chatSettings) {
+	if !settings.Probation.Enabled || settings.Probation.Messages == 0 {
+		return
+	}
+	inProbation, err := bot.db.IsInProbation(m.Chat.ID, m.Sender.ID)
+	if err != nil || !inProbation {
+		return
+	}
+
+	count, err := bot.db.CountProbationMessage(m.Chat.ID, m.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to count probation message")
+		return
+	}
+	if count >= int64(settings.Probation.Messages) {
+		bot.liftProbation(m.Chat, m.Sender, settings, "Clean messages")
+	}
+}
+
+// liftProbation ends the probation of the given user in the given chat,
+// removing the restrictions. If the user has been muted in the meantime, the
+// user remains muted.
+func (bot *telegramBot) liftProbation(chat *tb.Chat, user *tb.User, settings chatSettings, reason string) {
+	logfields := logrus.Fields{
+		"userid": user.ID,
+		"chatid": chat.ID,
+	}
+
+	if err := bot.db.RemoveProbation(chat.ID, user.ID); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("probation: failed to remove probation")
+		return
+	}
+
+	member, err := bot.telebot.ChatMemberOf(chat, user)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("probation: failed to get member object for user")
+		return
+	}
+	if member.Role != tb.Restricted || !member.CanSendMessages {
+		// The user left, or has been muted or promoted meanwhile.
+		return
+	}
+	member.Rights = tb.NoRestrictions()
+	member.RestrictedUntil = tb.Forever()
+	if err := bot.telebot.Restrict(chat, member); err != nil {
+		bot.logger.WithError(err).WithFields(logfields).Error("probation: failed to remove member restriction")
+		return
+	}
+
+	bot.logger.WithFields(logfields).WithField("reason", reason).Info("probation end")
+	settings.Log("probation end", bot.telebot.Me, member.User, reason)
+}
+
+// liftChatProbations ends the probation of all users in probation in the given
+// chat (e.g. when the probation is disabled).
+func (bot *telegramBot) liftChatProbations(chat *tb.Chat, settings chatSettings, reason string) {
+	users, err := bot.db.ChatProbations(chat.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to get users in probation")
+		return
+	}
+	for _, userID := range users {
+		bot.liftProbation(chat, &tb.User{ID: userID}, settings, reason)
+	}
+}
+
+// liftExpiredProbation ends the probation of the given user in the given chat,
+// if it expired by time. It is executed by the scheduler at the end of the
+// probation: if the user joined again meanwhile, the new probation is not
+// expired yet, and it is left as is.
+func (bot *telegramBot) liftExpiredProbation(chat *tb.Chat, user *tb.User, settings chatSettings) error {
+	until, found, err := bot.db.ProbationUntil(chat.ID, user.ID)
+	if err != nil {
+		return err
+	} else if !found || until.IsZero() || until.After(time.Now()) {
+		return nil
+	}
+	bot.liftProbation(chat, user, settings, "Probation time expired")
+	return nil
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	tb "gopkg.in/telebot.v3"
+)
+
+// quietHoursLocation returns the time zone of the given quiet hours. Unknown
+// time zones fall back to UTC.
+func quietHoursLocation(q database.QuietHours) *time.Location {
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// quietHoursActive returns true if the given quiet hours are active at the
+// given time. When the end hour is not after the start hour, the schedule
+// continues on the next day (e.g. from 22 to 7).
+func quietHoursActive(q database.QuietHours, t time.Time) bool {
+	if !q.Enabled {
+		return false
+	}
+	t = t.In(quietHoursLocation(q))
+	hour := uint(t.Hour())
+	startsOn := func(day time.Weekday) bool {
+		return q.Days&(1<<uint(day)) != 0
+	}
+
+	if q.Start < q.End {
+		return startsOn(t.Weekday()) && hour >= q.Start && hour < q.End
+	}
+	if hour >= q.Start {
+		return startsOn(t.Weekday())
+	}
+	return hour < q.End && startsOn((t.Weekday()+6)%7)
+}
+
+// quietHoursRights returns the default permissions during quiet hours with the
+// given profile, starting from the given default permissions.
+func quietHoursRights(profile int, perms tb.Rights) tb.Rights {
+	perms.CanSendMedia = false
+	perms.CanSendPolls = false
+	perms.CanSendOther = false
+	perms.CanAddPreviews = false
+	if profile == database.QuietReadOnly {
+		perms.CanSendMessages = false
+	}
+	return perms
+}
+
+// nextQuietHoursBoundary returns the first time after the given time when the
+// given quiet hours start or end. It returns false if the quiet hours never
+// start or end (e.g. they are disabled).
+func nextQuietHoursBoundary(q database.QuietHours, t time.Time) (time.Time, bool) {
+	if !q.Enabled {
+		return time.Time{}, false
+	}
+	local := t.In(quietHoursLocation(q))
+	next := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, local.Location())
+
+	// Boundaries are at the start of an hour, and the schedule is weekly.
+	for i := 0; i < 8*24; i++ {
+		next = next.Add(time.Hour)
+		if quietHoursActive(q, next) != quietHoursActive(q, next.Add(-time.Minute)) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// quietHoursJobID returns the ID of the job that updates the quiet hours of the
+// given chat at the given boundary. Jobs for the same boundary replace each
+// other.
+func quietHoursJobID(chatID int64, at time.Time) string {
+	return "quiethours:" + strconv.FormatInt(chatID, 10) + ":" + strconv.FormatInt(at.Unix(), 10)
+}
+
+// updateAllQuietHours updates the quiet hours in all chats. It's called at
+// startup, so boundaries crossed while the bot was down are applied, and the
+// next boundaries are scheduled.
+func (bot *telegramBot) updateAllQuietHours() error {
+	chats, err := bot.db.ListMyChats()
+	if err != nil {
+		return err
+	}
+	for _, chat := range chats {
+		settings, err := bot.getChatSettings(chat)
+		if err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Cannot get chat settings")
+			continue
+		}
+		if err := bot.updateQuietHours(chat, settings); err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to update quiet hours")
+		}
+	}
+	return nil
+}
+
+// updateQuietHours starts or ends the quiet hours in the given chat, according
+// to its schedule, and schedules a job at the next boundary (that calls
+// updateQuietHours again). It's called when the schedule changes too.
+//
+// Chats in lockdown are skipped: the lockdown restores the permissions at the
+// end, and the quiet hours are updated after that.
+func (bot *telegramBot) updateQuietHours(chat *tb.Chat, settings chatSettings) error {
+	now := time.Now()
+	if next, ok := nextQuietHoursBoundary(settings.QuietHours, now); ok {
+		bot.scheduleJob(database.Job{
+			ID:     quietHoursJobID(chat.ID, next),
+			Kind:   jobQuietHours,
+			ChatID: chat.ID,
+		}, next.Sub(now))
+	}
+
+	active := quietHoursActive(settings.QuietHours, now)
+	started, err := bot.db.IsInQuietHours(chat.ID)
+	if err != nil {
+		return err
+	} else if active == started {
+		return nil
+	}
+
+	if inLockdown, err := bot.db.IsInLockdown(chat.ID); err != nil || inLockdown {
+		return err
+	}
+
+	if active {
+		return bot.startQuietHours(chat, settings)
+	}
+	return bot.endQuietHours(chat, settings)
+}
+
+// startQuietHours restricts the default permissions of the given chat with the
+// quiet hours profile, saving the current ones.
+func (bot *telegramBot) startQuietHours(chat *tb.Chat, settings chatSettings) error {
+	perms := bot.chatPermissions(chat)
+	jsonb, err := json.Marshal(perms)
+	if err != nil {
+		return fmt.Errorf("error encoding chat permissions: %w", err)
+	}
+	if started, err := bot.db.StartQuietHours(chat.ID, jsonb); err != nil || !started {
+		return err
+	}
+
+	if err := bot.telebot.SetGroupPermissions(chat, quietHoursRights(settings.QuietHours.Profile, perms)); err != nil {
+		// Try again when the job is retried.
+		_, _ = bot.db.EndQuietHours(chat.ID)
+		return err
+	}
+	bot.logger.WithField("chatid", chat.ID).Info("quiet hours start")
+	settings.Log("quiet hours start", bot.telebot.Me, nil, "Quiet hours started")
+
+	if settings.QuietHours.Announce {
+		text := bot.bundle.T("", "Quiet hours have started: until %s only text messages are allowed.")
+		if settings.QuietHours.Profile == database.QuietReadOnly {
+			text = bot.bundle.T("", "Quiet hours have started: until %s nobody can send messages.")
+		}
+		end := fmt.Sprintf("%02d:00 (%s)", settings.QuietHours.End, quietHoursLocation(settings.QuietHours))
+		if _, err := bot.telebot.Send(chat, "🌙 "+fmt.Sprintf(text, end)); err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Warn("Failed to announce quiet hours")
+		}
+	}
+	return nil
+}
+
+// endQuietHours restores the default permissions of the given chat saved at
+// the start of the quiet hours.
+func (bot *telegramBot) endQuietHours(chat *tb.Chat, settings chatSettings) error {
+	jsonb, err := bot.db.EndQuietHours(chat.ID)
+	if errors.Is(err, database.ErrQuietHoursNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var perms tb.Rights
+	if err := json.Unmarshal(jsonb, &perms); err != nil {
+		return fmt.Errorf("error decoding chat permissions: %w", err)
+	}
+	if err := bot.telebot.SetGroupPermissions(chat, perms); err != nil {
+		// Try again when the job is retried.
+		_, _ = bot.db.StartQuietHours(chat.ID, jsonb)
+		return err
+	}
+	bot.logger.WithField("chatid", chat.ID).Info("quiet hours end")
+	settings.Log("quiet hours end", bot.telebot.Me, nil, "Quiet hours ended")
+
+	if settings.QuietHours.Announce {
+		if _, err := bot.telebot.Send(chat, "☀️ "+bot.bundle.T("", "Quiet hours have ended.")); err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Warn("Failed to announce quiet hours")
+		}
+	}
+	return nil
+}
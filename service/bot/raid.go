@@ -0,0 +1,322 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+const (
+	// defaultRaidWindow is the raid detection window, when not set in chat
+	// settings.
+	defaultRaidWindow = 60 * time.Second
+
+	// defaultLockdownDuration is the lockdown duration, when not set in chat
+	// settings.
+	defaultLockdownDuration = 30 * time.Minute
+)
+
+// endLockdownButton is the template of the "end lockdown" buttons in lockdown
+// alerts. The data of each button is the chat ID.
+var endLockdownButton = tb.InlineButton{Unique: "lockdown_end"}
+
+// lockdownDuration returns the lockdown duration in the given chat settings.
+func lockdownDuration(settings chatSettings) time.Duration {
+	if settings.Raid.Lockdown > 0 {
+		return time.Duration(settings.Raid.Lockdown) * time.Second
+	}
+	return defaultLockdownDuration
+}
+
+// raidFilter records the join of the given user, and checks whether the chat
+// is being raided (more than Raid.Joins joins in Raid.Window). When a raid is
+// detected, the chat goes in lockdown. It returns true if the chat is in
+// lockdown: in this case the user has been muted until the lockdown end time
+// (see endLockdown).
+func (bot *telegramBot) raidFilter(m *tb.Message, user *tb.User, settings chatSettings) bool {
+	if settings.Raid.Joins == 0 || user.IsBot {
+		return false
+	}
+	logger := bot.logger.WithFields(logrus.Fields{
+		"chatid": m.Chat.ID,
+		"userid": user.ID,
+	})
+
+	until, inLockdown, err := bot.db.LockdownUntil(m.Chat.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check lockdown")
+		return false
+	} else if inLockdown {
+		if err := bot.muteUser(m.Chat, user, settings, lockdownMuteDuration(until), "Joined during lockdown"); err == nil {
+			if err := bot.db.AddLockdownMuted(m.Chat.ID, user.ID); err != nil {
+				logger.WithError(err).Error("Failed to record user muted during lockdown")
+			}
+		}
+		return true
+	}
+
+	window := defaultRaidWindow
+	if settings.Raid.Window > 0 {
+		window = time.Duration(settings.Raid.Window) * time.Second
+	}
+	joiners, err := bot.db.AddJoin(m.Chat.ID, user.ID, window)
+	if err != nil {
+		logger.WithError(err).Error("Failed to record join for raid detection")
+		return false
+	} else if uint(len(joiners)) <= settings.Raid.Joins {
+		return false
+	}
+
+	until = bot.startLockdown(m.Chat, settings, fmt.Sprintf("Raid: %d joins in %s", len(joiners), window))
+	if until.IsZero() {
+		until = time.Now().Add(lockdownDuration(settings))
+	}
+	var muted []int64
+	for _, id := range joiners {
+		if err := bot.muteUser(m.Chat, &tb.User{ID: id}, settings, lockdownMuteDuration(until), "Joined during raid"); err == nil {
+			muted = append(muted, id)
+		}
+	}
+	if err := bot.db.AddLockdownMuted(m.Chat.ID, muted...); err != nil {
+		logger.WithError(err).Error("Failed to record users muted during lockdown")
+	}
+	return true
+}
+
+// lockdownMuteDuration returns the duration of the mute of users joining during
+// a lockdown that ends at the given time. The mute is lifted by endLockdown:
+// the duration is a fallback, so it's at least minRestrictDuration (shorter
+// restrictions are forever for Telegram).
+func lockdownMuteDuration(until time.Time) time.Duration {
+	if d := time.Until(until); d > minRestrictDuration {
+		return d
+	}
+	return minRestrictDuration
+}
+
+// chatPermissions returns the current default permissions of the given chat.
+// If they cannot be fetched, members are allowed to send anything.
+func (bot *telegramBot) chatPermissions(chat *tb.Chat) tb.Rights {
+	perms := tb.Rights{CanSendMessages: true, CanSendMedia: true, CanSendPolls: true, CanSendOther: true, CanAddPreviews: true}
+	if info, err := bot.telebot.ChatByID(chat.ID); err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Warn("Failed to get chat permissions, using defaults")
+	} else if info.Permissions != nil {
+		perms = *info.Permissions
+	}
+	return perms
+}
+
+// startLockdown puts the given chat in lockdown: members cannot send anything
+// (using the default chat permissions) until an admin ends the lockdown, or
+// until the lockdown duration expires. Admins are alerted in the chat, in
+// private (in their language) and in the log channel.
+//
+// It returns the end time of the lockdown of the chat, zero if it cannot be
+// started.
+func (bot *telegramBot) startLockdown(chat *tb.Chat, settings chatSettings, reason string) time.Time {
+	logger := bot.logger.WithField("chatid", chat.ID)
+
+	// Save the current default permissions, to restore them later.
+	jsonb, err := json.Marshal(bot.chatPermissions(chat))
+	if err != nil {
+		logger.WithError(err).Error("Failed to encode chat permissions")
+		return time.Time{}
+	}
+	now := time.Now()
+	duration := lockdownDuration(settings)
+	lockdown := database.Lockdown{
+		ID:          strconv.FormatInt(now.UnixNano(), 10),
+		Permissions: jsonb,
+		Until:       now.Add(duration),
+	}
+	started, err := bot.db.StartLockdown(chat.ID, lockdown)
+	if err != nil {
+		logger.WithError(err).Error("Failed to save lockdown")
+		return time.Time{}
+	} else if !started {
+		// Already started by another join.
+		until, _, err := bot.db.LockdownUntil(chat.ID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to check lockdown")
+		}
+		return until
+	}
+
+	if err := bot.telebot.SetGroupPermissions(chat, tb.NoRights()); err != nil {
+		logger.WithError(err).Error("Failed to restrict chat permissions for lockdown")
+	}
+	bot.scheduleJob(database.Job{
+		ID:         lockdownJobID(chat.ID, lockdown.ID),
+		Kind:       jobEndLockdown,
+		ChatID:     chat.ID,
+		LockdownID: lockdown.ID,
+	}, duration)
+
+	logger.WithField("reason", reason).Warn("lockdown start")
+	settings.Log("lockdown start", bot.telebot.Me, nil, reason)
+
+	// Alert in the chat and to each admin in private, in the language of each
+	// admin.
+	alert := func(lang string) (string, *tb.ReplyMarkup) {
+		text := fmt.Sprintf("🚨 %s\n%s", reason, fmt.Sprintf(bot.bundle.T(lang, "This chat is in lockdown for %s: new members are muted and nobody can send messages."), prettyDuration(uint(duration.Seconds()), bot, lang)))
+		bt := endLockdownButton
+		bt.Text = "🔓 " + bot.bundle.T(lang, "End lockdown")
+		bt.Data = strconv.FormatInt(chat.ID, 10)
+		return text, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{bt}}}
+	}
+
+	text, markup := alert("")
+	if _, err := bot.telebot.Send(chat, text, markup); err != nil {
+		logger.WithError(err).Error("Failed to send lockdown alert")
+	}
+	for _, adminID := range settings.ChatAdmins {
+		if adminID == bot.telebot.Me.ID {
+			continue
+		}
+		text, markup := alert(bot.userLanguage(adminID))
+		if _, err := bot.telebot.Send(&tb.Chat{ID: adminID}, chat.Title+": "+text, markup); err != nil {
+			logger.WithError(err).WithField("adminid", adminID).Debug("Failed to send lockdown alert to admin")
+		}
+	}
+	return lockdown.Until
+}
+
+// lockdownJobID returns the ID of the job that ends the given lockdown of the
+// given chat.
+func lockdownJobID(chatID int64, lockdownID string) string {
+	return "lockdown:" + strconv.FormatInt(chatID, 10) + ":" + lockdownID
+}
+
+// endLockdown ends the lockdown of the given chat, restoring the default
+// permissions, and lifting the mutes of the users that joined during the
+// lockdown. If id is not empty, the lockdown is ended only if it has the given
+// ID (e.g. the scheduled end of a previous lockdown is ignored).
+//
+// It returns database.ErrLockdownNotFound if the chat is not in lockdown (e.g.
+// it was already ended).
+func (bot *telegramBot) endLockdown(chat *tb.Chat, by *tb.User, id string) error {
+	lockdown, err := bot.db.EndLockdown(chat.ID, id)
+	if err != nil {
+		return err
+	}
+
+	var perms tb.Rights
+	if err := json.Unmarshal(lockdown.Permissions, &perms); err != nil {
+		return fmt.Errorf("error decoding chat permissions: %w", err)
+	}
+	if err := bot.telebot.SetGroupPermissions(chat, perms); err != nil {
+		// Keep the lockdown, so it can be ended again.
+		if _, dberr := bot.db.StartLockdown(chat.ID, lockdown); dberr != nil {
+			bot.logger.WithError(dberr).WithField("chatid", chat.ID).Error("Failed to restore lockdown")
+		}
+		return err
+	}
+
+	// The lockdown is over, the scheduled end is not needed anymore (if it's
+	// not the scheduled end itself).
+	if lockdown.ID != "" && id == "" {
+		if err := bot.db.CompleteJob(lockdownJobID(chat.ID, lockdown.ID)); err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to cancel the end of lockdown")
+		}
+	}
+
+	settings, err := bot.getChatSettings(chat)
+	if err != nil {
+		return err
+	}
+	if by == nil {
+		by = bot.telebot.Me
+	}
+	bot.logger.WithField("chatid", chat.ID).WithField("by", by.ID).Info("lockdown end")
+	settings.Log("lockdown end", by, nil, "Lockdown ended")
+
+	msg, err := bot.telebot.Send(chat, "🔓 "+bot.bundle.T("", "The lockdown has ended"))
+	if err == nil {
+		bot.setMessageExpiry(msg, 1*time.Minute)
+	}
+
+	for _, userID := range lockdown.Muted {
+		bot.liftLockdownMute(chat, &tb.User{ID: userID}, settings)
+	}
+
+	// Quiet hours boundaries are skipped during the lockdown.
+	if err := bot.updateQuietHours(chat, settings); err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to update quiet hours")
+	}
+	return nil
+}
+
+// liftLockdownMute removes the mute of the given user, muted because they
+// joined the given chat during a lockdown. As the user skipped the checks for
+// new members, the user goes through the CAPTCHA (or the probation) now.
+//
+// Users that left, or that have been banned or unmuted meanwhile, are left
+// untouched.
+func (bot *telegramBot) liftLockdownMute(chat *tb.Chat, user *tb.User, settings chatSettings) {
+	member, err := bot.telebot.ChatMemberOf(chat, user)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logrus.Fields{
+			"userid": user.ID,
+			"chatid": chat.ID,
+		}).Error("lockdown: failed to get member object for user")
+		return
+	}
+	if member.Role != tb.Restricted || member.CanSendMessages {
+		return
+	}
+	if !bot.startCaptcha(chat, member.User, settings) {
+		bot.passCaptcha(chat, member.User, settings)
+	}
+}
+
+// onEndLockdown is fired when an admin presses the "end lockdown" button.
+func (bot *telegramBot) onEndLockdown(ctx tb.Context) error {
+	callback := ctx.Callback()
+	if callback == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Callback, ignored")
+		return nil
+	}
+	lang := callback.Sender.LanguageCode
+
+	chatID, err := strconv.ParseInt(callback.Data, 10, 64)
+	if err != nil {
+		bot.logger.WithError(err).Error("Failed to parse callback data as int64")
+		return ctx.Respond()
+	}
+	chat := &tb.Chat{ID: chatID}
+
+	settings, err := bot.getChatSettings(chat)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chatID).Error("Cannot get chat settings")
+		return ctx.Respond()
+	}
+	isGlobalAdmin, err := bot.db.IsBotAdmin(callback.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).Error("Failed to check if the user is a global admin")
+		return ctx.Respond()
+	}
+	if !settings.ChatAdmins.IsAdmin(callback.Sender) && !isGlobalAdmin {
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "Sorry, only group admins can use this command")})
+	}
+
+	err = bot.endLockdown(chat, callback.Sender, "")
+	if errors.Is(err, database.ErrLockdownNotFound) {
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "The lockdown has ended")})
+	} else if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chatID).Error("Failed to end lockdown")
+		return ctx.Respond(&tb.CallbackResponse{Text: bot.bundle.T(lang, "Sorry, I cannot do that. Please check my permissions in this group")})
+	}
+
+	_ = ctx.Respond(&tb.CallbackResponse{Text: "Ok"})
+	if callback.Message != nil {
+		_, _ = bot.telebot.EditReplyMarkup(callback.Message, nil)
+	}
+	return nil
+}
@@ -95,6 +95,12 @@ func (bot *telegramBot) refreshDBInfo(handler contextualChatSettingsFunc) tb.Han
 			}
 		}
 
+		// Remember the language of users writing in private, so messages sent
+		// to them later (e.g. report notifications) are in their language.
+		if sender := ctx.Sender(); chat.Type == tb.ChatPrivate && sender != nil && sender.LanguageCode != "" {
+			bot.saveUserLanguage(sender.ID, sender.LanguageCode)
+		}
+
 		// Updates from private chats don't need chat settings, only groups.
 		handler(ctx, settings)
 		return nil
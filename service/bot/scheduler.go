@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// Kinds of scheduled jobs.
+const (
+	// jobExpire deletes a message without recording it in the log (e.g. bot replies)
+	jobExpire = "expire"
+
+	// jobDelete deletes a message, recording it in the log
+	jobDelete = "delete"
+
+	// jobMute mutes a user, and deletes the message (if any)
+	jobMute = "mute"
+
+	// jobBan bans a user, and deletes the message (if any)
+	jobBan = "ban"
+
+	// jobKick kicks a user, and deletes the message (if any)
+	jobKick = "kick"
+
+	// jobUnrestrict removes all restrictions of a user
+	jobUnrestrict = "unrestrict"
+
+	// jobLiftProbation ends the probation of a user, if expired
+	jobLiftProbation = "lift_probation"
+
+	// jobEndLockdown ends the lockdown of a chat
+	jobEndLockdown = "end_lockdown"
+
+	// jobQuietHours starts or ends the quiet hours of a chat
+	jobQuietHours = "quiet_hours"
+
+	// jobExpireCaptcha kicks a user that did not answer the join challenge in
+	// time
+	jobExpireCaptcha = "expire_captcha"
+)
+
+const (
+	// jobLease is the time after which a claimed job that didn't complete is
+	// executed again.
+	jobLease = 2 * time.Minute
+
+	// jobBatch is the maximum number of jobs claimed at once.
+	jobBatch = 100
+
+	// jobMaxAttempts is the number of failed executions after which a job is
+	// dropped.
+	jobMaxAttempts = 5
+)
+
+// scheduleJob schedules the given job after the given delay. Jobs are stored in
+// the database, so they survive a bot reboot. Errors are logged.
+func (bot *telegramBot) scheduleJob(job database.Job, delay time.Duration) {
+	if _, err := bot.db.ScheduleJob(job, time.Now().Add(delay)); err != nil {
+		bot.logger.WithError(err).WithFields(logrus.Fields{
+			"chatid": job.ChatID,
+			"kind":   job.Kind,
+		}).Error("Failed to schedule job")
+	}
+}
+
+// scheduleAction schedules the given action on the given message and user
+// after the action delay.
+func (bot *telegramBot) scheduleAction(message *tb.Message, user *tb.User, action database.BotAction, reason string) {
+	job := database.Job{
+		ChatID:    message.Chat.ID,
+		UserID:    user.ID,
+		MessageID: message.ID,
+		Duration:  action.Duration,
+		Reason:    reason,
+	}
+	switch action.Action {
+	case database.ActionMute:
+		job.Kind = jobMute
+	case database.ActionBan:
+		job.Kind = jobBan
+	case database.ActionKick:
+		job.Kind = jobKick
+	case database.ActionDeleteMsg:
+		job.Kind = jobDelete
+	default:
+		return
+	}
+	bot.scheduleJob(job, time.Duration(action.Delay)*time.Second)
+}
+
+// runDueJobs executes all due jobs. Failed jobs are retried with exponential
+// backoff if the error is temporary, up to jobMaxAttempts times.
+//
+// Jobs are executed at least once: if the bot stops while executing jobs, they
+// are claimed again after jobLease.
+func (bot *telegramBot) runDueJobs() error {
+	for {
+		jobs, corrupt, err := bot.db.ClaimDueJobs(time.Now(), jobLease, jobBatch)
+		if err != nil {
+			return err
+		}
+		for _, id := range corrupt {
+			bot.logger.WithField("jobid", id).Error("Undecodable job dropped")
+		}
+		for _, job := range jobs {
+			bot.runJob(job)
+		}
+		if len(jobs)+len(corrupt) < jobBatch {
+			return nil
+		}
+	}
+}
+
+// runJob executes the given claimed job, and then completes it or schedules a
+// retry.
+func (bot *telegramBot) runJob(job database.Job) {
+	logger := bot.logger.WithFields(logrus.Fields{
+		"jobid":  job.ID,
+		"kind":   job.Kind,
+		"chatid": job.ChatID,
+	})
+
+	err := bot.executeJob(job)
+	if err == nil {
+		bot.schedulerJobsTotal.WithLabelValues(job.Kind, "done").Inc()
+		if err := bot.db.CompleteJob(job.ID); err != nil {
+			logger.WithError(err).Error("Failed to complete job")
+		}
+		return
+	}
+
+	job.Attempts++
+	if !isTemporaryError(err) || job.Attempts >= jobMaxAttempts {
+		logger.WithError(err).WithField("attempts", job.Attempts).Warn("Job failed, dropped")
+		bot.schedulerJobsTotal.WithLabelValues(job.Kind, "failed").Inc()
+		if err := bot.db.CompleteJob(job.ID); err != nil {
+			logger.WithError(err).Error("Failed to complete job")
+		}
+		return
+	}
+
+	logger.WithError(err).WithField("attempts", job.Attempts).Info("Job failed, retrying")
+	bot.schedulerJobsTotal.WithLabelValues(job.Kind, "retry").Inc()
+	if _, err := bot.db.ScheduleJob(job, time.Now().Add(retryDelay(err, job.Attempts))); err != nil {
+		logger.WithError(err).Error("Failed to reschedule job")
+	}
+}
+
+// executeJob performs the given job.
+func (bot *telegramBot) executeJob(job database.Job) error {
+	chat := &tb.Chat{ID: job.ChatID}
+	if job.Kind == jobExpire {
+		err := bot.telebot.Delete(&tb.Message{ID: job.MessageID, Chat: chat})
+		if errors.Is(err, tb.ErrNotFoundToDelete) {
+			// Already deleted.
+			return nil
+		}
+		return err
+	} else if job.Kind == jobExpireCaptcha {
+		return bot.expireCaptcha(chat, &tb.User{ID: job.UserID}, job.MessageID)
+	} else if job.Kind == jobEndLockdown {
+		err := bot.endLockdown(chat, nil, job.LockdownID)
+		if errors.Is(err, database.ErrLockdownNotFound) {
+			// Already ended by an admin, or another lockdown started.
+			return nil
+		}
+		return err
+	}
+
+	settings, err := bot.getChatSettings(chat)
+	if err != nil {
+		return err
+	}
+	user := &tb.User{ID: job.UserID}
+	duration := time.Duration(job.Duration) * time.Second
+
+	switch job.Kind {
+	case jobMute:
+		err = bot.muteUser(chat, user, settings, duration, job.Reason)
+	case jobBan:
+		err = bot.banUser(chat, user, settings, duration, job.Reason)
+	case jobKick:
+		err = bot.kickUser(chat, user, settings, job.Reason)
+	case jobUnrestrict:
+		return bot.unrestrictUser(chat, user, settings, job.Reason)
+	case jobLiftProbation:
+		return bot.liftExpiredProbation(chat, user, settings)
+	case jobQuietHours:
+		return bot.updateQuietHours(chat, settings)
+	case jobDelete:
+	default:
+		return fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+	if err != nil || job.MessageID == 0 {
+		return err
+	}
+
+	err = bot.deleteMessage(&tb.Message{ID: job.MessageID, Chat: chat, Sender: user}, settings, job.Reason)
+	if errors.Is(err, tb.ErrNotFoundToDelete) {
+		// Already deleted (e.g. by an admin).
+		return nil
+	}
+	return err
+}
+
+// isTemporaryError returns false for Telegram errors that won't go away by
+// retrying (bad requests and missing permissions), true otherwise.
+func isTemporaryError(err error) bool {
+	var tberr *tb.Error
+	if errors.As(err, &tberr) {
+		return tberr.Code != 400 && tberr.Code != 403
+	}
+	return true
+}
+
+// retryDelay returns the delay before retrying a job after the given error and
+// number of attempts.
+func retryDelay(err error, attempts int) time.Duration {
+	var flood tb.FloodError
+	if errors.As(err, &flood) && flood.RetryAfter > 0 {
+		return time.Duration(flood.RetryAfter) * time.Second
+	}
+	return (10 * time.Second) << uint(attempts-1)
+}
@@ -3,18 +3,20 @@ package bot
 import (
 	"time"
 
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
 	tb "gopkg.in/telebot.v3"
 )
 
 // setMessageExpiry sets the given expiration for the given message. In other
 // words, the message m will be deleted after exp time.
 //
-// This function launches a goroutine. All TTLs won't survive a bot reboot
-// (messages won't be deleted).
+// The deletion is a scheduled job (see scheduler.go), so it survives a bot
+// reboot.
 func (bot *telegramBot) setMessageExpiry(m *tb.Message, exp time.Duration) {
-	t := time.NewTimer(exp)
-	go func(m *tb.Message) {
-		<-t.C
-		_ = bot.telebot.Delete(m)
-	}(m)
+	bot.scheduleJob(database.Job{
+		Kind:      jobExpire,
+		ChatID:    m.Chat.ID,
+		MessageID: m.ID,
+	}, exp)
 }
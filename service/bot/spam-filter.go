@@ -1,42 +1,152 @@
 package bot
 
 import (
+	"strconv"
+	"time"
+
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
 
+	"github.com/patrickmn/go-cache"
 	tb "gopkg.in/telebot.v3"
 )
 
-// spamFilter checks all text values in the slice against antispam functions. If
-// a violation is detected, the corresponding action will be performed.
+// spamFilter checks all text values in the slice against the registered
+// antispam detectors. If a detector score is above its threshold, the
+// corresponding action will be performed, and it returns true.
+//
+// Text values are normalized (see antispam.Normalize) before detection, except
+// for antispam.RawDetector detectors. Text mentions in message entities are
+// passed to antispam.HiddenMentionsDetector detectors. When the normalized
+// text differs, the original text is added to the reason in the log.
 //
 // Example: if the action on Chinese messages is delete, the bot will delete the
 // message.
 //
-// Time complexity: O(n*2m) where n is the length of the textvalues slice, m is
-// the length of the longest string in the slice
-func (bot *telegramBot) spamFilter(m *tb.Message, settings chatSettings, textvalues []string) {
-	for _, text := range textvalues {
-		// Note: nothing personal. We were forced to write these blocks for
-		// chinese texts in a period of time when bots were targetting our
-		// group. This check is trying to avoid banning people randomly just for
-		// having chinese/arabic names, however false positive might arise.
-		if settings.OnMessageChinese.Action != database.ActionNone {
-			chinesePercent := antispam.ChineseChars(text)
-			bot.logger.Debugf("SPAM detection (msg id %d): chinese %f", m.ID, chinesePercent)
-			if chinesePercent > 0.05 {
-				bot.performAction(m, m.Sender, settings, settings.OnMessageChinese, "Chinese message filter enabled")
-				return
+// Time complexity: O(n*d*m) where n is the length of the textvalues slice, d is
+// the number of detectors and m is the length of the longest string in the
+// slice
+func (bot *telegramBot) spamFilter(m *tb.Message, settings chatSettings, textvalues []string) bool {
+	// Text mentions are not part of the text.
+	textMentions := countEntities(m.Entities, tb.EntityTMention) + countEntities(m.CaptionEntities, tb.EntityTMention)
+
+	// Detectors built from chat settings are the same for all text values.
+	detectors := bot.chatDetectors(m.Chat, settings)
+
+	for _, original := range textvalues {
+		text := antispam.Normalize(original)
+
+		// Note: nothing personal. We were forced to write the chinese and
+		// arabic detectors in a period of time when bots were targetting our
+		// group. These checks are trying to avoid banning people randomly just
+		// for having chinese/arabic names, however false positive might arise.
+		for _, detector := range detectors {
+			detectorSettings := settings.Detector(detector.Name())
+			if detectorSettings.Action.Action == database.ActionNone {
+				continue
+			}
+
+			threshold := detectorSettings.Threshold
+			if threshold == 0 {
+				threshold = detector.Threshold()
 			}
-		}
 
-		if settings.OnMessageArabic.Action != database.ActionNone {
-			arabicPercent := antispam.ArabicChars(text)
-			bot.logger.Debugf("SPAM detection (msg id %d): arabic %f", m.ID, arabicPercent)
-			if arabicPercent > 0.05 {
-				bot.performAction(m, m.Sender, settings, settings.OnMessageChinese, "Arabic message filter enabled")
-				return
+			var score float64
+			var reason string
+			if raw, ok := detector.(antispam.RawDetector); ok {
+				score, reason = raw.DetectRaw(original)
+			} else if md, ok := detector.(antispam.HiddenMentionsDetector); ok {
+				score, reason = md.DetectMentions(text, textMentions)
+			} else {
+				score, reason = detector.Detect(text)
 			}
+			bot.logger.Debugf("SPAM detection (msg id %d): %s %f", m.ID, detector.Name(), score)
+			if score > threshold {
+				if text != original {
+					reason += "\nOriginal text: " + original
+				}
+				bot.performAction(m, m.Sender, settings, detectorSettings.Action, reason)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// chatDetectors returns the detectors for the given chat: the ones in the bot
+// registry followed by the ones built from the chat settings.
+func (bot *telegramBot) chatDetectors(chat *tb.Chat, settings chatSettings) []antispam.Detector {
+	detectors := append([]antispam.Detector(nil), bot.detectors.Detectors()...)
+
+	if len(settings.Scripts) > 0 {
+		script, err := antispam.NewScriptDetectorFromNames("script", settings.Scripts...)
+		if err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Warn("Invalid script in chat settings")
+		} else {
+			detectors = append(detectors, script)
 		}
 	}
+
+	if len(settings.LinkDeny) > 0 || settings.LinkBlockAll {
+		detectors = append(detectors, antispam.NewLinkDetector(settings.LinkAllow, settings.LinkDeny, settings.LinkBlockAll))
+	}
+	if settings.Detector("keywords").Action.Action != database.ActionNone {
+		if keywords, err := bot.keywordDetector(chat); err != nil {
+			bot.logger.WithError(err).WithField("chatid", chat.ID).Warn("Failed to load keyword blocklist")
+		} else {
+			detectors = append(detectors, keywords)
+		}
+	}
+	return detectors
+}
+
+// keywordDetectorTTL is how long a keyword detector is cached. Blocklist edits
+// are applied immediately on this instance (see invalidateKeywordDetector), and
+// after keywordDetectorTTL on other replicas.
+const keywordDetectorTTL = 5 * time.Minute
+
+// keywordDetector returns the keyword detector for the blocklist of the given
+// chat, from the cache if possible. Each match is counted in metrics by the
+// position of the rule in the blocklist, and the matching rule is logged.
+func (bot *telegramBot) keywordDetector(chat *tb.Chat) (antispam.Detector, error) {
+	chatID := strconv.FormatInt(chat.ID, 10)
+	if detector, found := bot.keywordDetectors.Get(chatID); found {
+		return detector.(antispam.Detector), nil
+	}
+
+	rules, err := bot.db.GetKeywords(chat.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The position in the blocklist of each keyword and regular expression,
+	// used as metric label: it is bounded, unlike the rule itself.
+	var keywords, regexps []string
+	var keywordPos, regexpPos []int
+	for i, rule := range rules {
+		if rule.Regexp {
+			regexps = append(regexps, rule.Pattern)
+			regexpPos = append(regexpPos, i)
+		} else {
+			keywords = append(keywords, rule.Pattern)
+			keywordPos = append(keywordPos, i)
+		}
+	}
+	positions := append(keywordPos, regexpPos...)
+
+	detector, err := antispam.NewKeywordDetector(keywords, regexps, func(index int, rule string) {
+		bot.keywordMatchTotal.WithLabelValues(strconv.Itoa(positions[index])).Inc()
+		bot.logger.WithField("chatid", chatID).WithField("rule", rule).Info("Keyword blocklist match")
+	})
+	if err != nil {
+		return nil, err
+	}
+	bot.keywordDetectors.Set(chatID, detector, cache.DefaultExpiration)
+	return detector, nil
+}
+
+// invalidateKeywordDetector removes the cached keyword detector of the given
+// chat. It must be called when the blocklist is edited.
+func (bot *telegramBot) invalidateKeywordDetector(chatID int64) {
+	bot.keywordDetectors.Delete(strconv.FormatInt(chatID, 10))
 }
@@ -29,6 +29,22 @@ type State struct {
 	// the sub category
 	AddSubCategory bool
 
+	// AddLinkAllow is a flag indicating that the next message is a list of
+	// domains to add to the link allow list of ChatToEdit
+	AddLinkAllow bool
+
+	// AddLinkDeny is a flag indicating that the next message is a list of
+	// domains to add to the link deny list of ChatToEdit
+	AddLinkDeny bool
+
+	// AddKeyword is a flag indicating that the next message is a list of
+	// keywords to add to the blocklist of ChatToEdit
+	AddKeyword bool
+
+	// AddKeywordRegexp is a flag indicating that the next message is a list of
+	// regular expressions to add to the blocklist of ChatToEdit
+	AddKeywordRegexp bool
+
 	bot             *telegramBot
 	user            *tb.User
 	chatWithTheUser *tb.Chat
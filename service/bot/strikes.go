@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v3"
+)
+
+// strikeLadders are the selectable escalation ladders in the strikes settings
+// panel. The first one is the default.
+var strikeLadders = [][]database.BotAction{
+	{
+		{Action: database.ActionDeleteMsg},
+		{Action: database.ActionMute, Duration: 3600},
+		{Action: database.ActionBan},
+	},
+	{
+		{Action: database.ActionDeleteMsg},
+		{Action: database.ActionDeleteMsg},
+		{Action: database.ActionMute, Duration: 24 * 3600},
+		{Action: database.ActionBan},
+	},
+	{
+		{Action: database.ActionMute, Duration: 3600},
+		{Action: database.ActionMute, Duration: 24 * 3600},
+		{Action: database.ActionBan},
+	},
+}
+
+// defaultStrikeDecay is the strike decay (in seconds) used when strikes are
+// enabled for the first time.
+const defaultStrikeDecay = 30 * 24 * 3600
+
+// strikeLadder returns the escalation ladder in the given chat settings, or the
+// default one if not set.
+func strikeLadder(settings chatSettings) []database.BotAction {
+	if len(settings.Strikes.Ladder) == 0 {
+		return strikeLadders[0]
+	}
+	return settings.Strikes.Ladder
+}
+
+// strikeAction returns the action in the given ladder for the given number of
+// strikes. Strikes beyond the ladder use the last action.
+func strikeAction(ladder []database.BotAction, strikes int64) database.BotAction {
+	if strikes < 1 {
+		strikes = 1
+	} else if strikes > int64(len(ladder)) {
+		strikes = int64(len(ladder))
+	}
+	return ladder[strikes-1]
+}
+
+// performStrike adds a strike to the given user, warns the user, and does the
+// action for the new strike level on the given message.
+func (bot *telegramBot) performStrike(message *tb.Message, user *tb.User, settings chatSettings, reason string) {
+	ladder := strikeLadder(settings)
+	strikes, err := bot.db.AddStrike(message.Chat.ID, user.ID, time.Duration(settings.Strikes.Decay)*time.Second)
+	if err != nil {
+		bot.logger.WithError(err).WithFields(logrus.Fields{
+			"chatid": message.Chat.ID,
+			"userid": user.ID,
+		}).Error("Failed to add strike, using the first action of the ladder")
+		strikes = 1
+	}
+
+	bot.sendStrikeWarning(message.Chat, user, strikes, int64(len(ladder)), reason)
+	reason = fmt.Sprintf("%s (strike %d/%d)", reason, strikes, len(ladder))
+	bot.enforceAction(message, user, settings, strikeAction(ladder, strikes), reason)
+}
+
+// sendStrikeWarning sends a warning about the given strike to the given user in
+// the given chat. The warning expires after one minute.
+func (bot *telegramBot) sendStrikeWarning(chat *tb.Chat, user *tb.User, strikes int64, max int64, reason string) {
+	lang := user.LanguageCode
+	msg, err := bot.telebot.Send(chat, fmt.Sprintf(bot.bundle.T(lang, "⚠️ %s, warning %d/%d: %s"), userDisplayName(user), strikes, max, reason))
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", chat.ID).Error("Failed to send strike warning")
+		return
+	}
+	bot.setMessageExpiry(msg, 1*time.Minute)
+}
+
+// onWarn is fired on /warn command. It works only in groups, if the command is
+// given as a reply for another message: the sender of that message gets a
+// strike, and the action for the new strike level is done on the message. The
+// text after the command, if any, is the reason.
+func (bot *telegramBot) onWarn(ctx tb.Context, settings chatSettings) {
+	m := ctx.Message()
+	if m == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+		return
+	}
+	_ = ctx.Delete()
+	if m.Private() || !m.IsReply() || m.ReplyTo.Sender == nil {
+		return
+	}
+	bot.botCommandsRequestsTotal.WithLabelValues("warn").Inc()
+
+	if settings.ChatAdmins.IsAdmin(m.ReplyTo.Sender) {
+		return
+	}
+
+	reason := "Warned by an admin"
+	if m.Payload != "" {
+		reason = m.Payload
+	}
+	bot.performStrike(m.ReplyTo, m.ReplyTo.Sender, settings, reason)
+}
+
+// onUnwarn is fired on /unwarn command. It works only in groups, if the command
+// is given as a reply for another message: a strike is removed from the sender
+// of that message.
+func (bot *telegramBot) onUnwarn(ctx tb.Context, settings chatSettings) {
+	m := ctx.Message()
+	if m == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+		return
+	}
+	_ = ctx.Delete()
+	if m.Private() || !m.IsReply() || m.ReplyTo.Sender == nil {
+		return
+	}
+	bot.botCommandsRequestsTotal.WithLabelValues("unwarn").Inc()
+
+	lang := m.Sender.LanguageCode
+	strikes, err := bot.db.RemoveStrike(m.Chat.ID, m.ReplyTo.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to remove strike")
+		msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+		bot.setMessageExpiry(msg, 10*time.Second)
+		return
+	}
+	settings.Log("unwarn", m.Sender, m.ReplyTo.Sender, fmt.Sprintf("Strike removed, %d left", strikes))
+
+	msg, _ := bot.telebot.Send(m.Chat, fmt.Sprintf(bot.bundle.T(lang, "Warning removed, %s has %d warnings now"), userDisplayName(m.ReplyTo.Sender), strikes))
+	bot.setMessageExpiry(msg, 30*time.Second)
+}
+
+// onWarns is fired on /warns command. It works only in groups, if the command
+// is given as a reply for another message: it shows the number of strikes of
+// the sender of that message.
+func (bot *telegramBot) onWarns(ctx tb.Context, settings chatSettings) {
+	m := ctx.Message()
+	if m == nil {
+		bot.logger.WithField("updateid", ctx.Update().ID).Warn("Update with nil on Message, ignored")
+		return
+	}
+	_ = ctx.Delete()
+	if m.Private() || !m.IsReply() || m.ReplyTo.Sender == nil {
+		return
+	}
+	bot.botCommandsRequestsTotal.WithLabelValues("warns").Inc()
+
+	lang := m.Sender.LanguageCode
+	strikes, err := bot.db.GetStrikes(m.Chat.ID, m.ReplyTo.Sender.ID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("chatid", m.Chat.ID).Error("Failed to get strikes")
+		msg, _ := bot.telebot.Send(m.Chat, bot.bundle.T(lang, "Oops, I'm broken, please get in touch with my admin!"))
+		bot.setMessageExpiry(msg, 10*time.Second)
+		return
+	}
+
+	ladder := strikeLadder(settings)
+	next := prettyStrikeAction(strikeAction(ladder, strikes+1), bot, lang)
+	msg, _ := bot.telebot.Send(m.Chat, fmt.Sprintf(bot.bundle.T(lang, "%s has %d warnings, next action: %s"), userDisplayName(m.ReplyTo.Sender), strikes, next))
+	bot.setMessageExpiry(msg, 30*time.Second)
+}
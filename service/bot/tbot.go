@@ -19,7 +19,10 @@ package bot
 
 import (
 	"net/http"
+	"time"
 
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/antispam"
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/banlist"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/cas"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/database"
 	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/i18n"
@@ -53,6 +56,26 @@ type telegramBot struct {
 	// cas is the CAS database interface, if any
 	cas cas.CAS
 
+	// banlists are all ban lists, CAS included, in lookup order
+	banlists banlist.Providers
+
+	// detectors is the registry of antispam detectors used by spamFilter
+	detectors *antispam.Registry
+
+	// classifier is the spam classifier, trained by admins with /spam and /ham commands. Its model is shared by all
+	// chats
+	classifier *antispam.BayesClassifier
+
+	// duplicateChats is the number of chats above which a message sent by the same user is a network duplicate. Zero
+	// disables the detection
+	duplicateChats uint
+
+	// duplicateWindow is the time window for the network duplicate detection
+	duplicateWindow time.Duration
+
+	// duplicateGLine enables the automatic G-Line of users sending network duplicates
+	duplicateGLine bool
+
 	// Bundle is the Bundle instance to get localized strings.
 	bundle *i18n.Bundle
 
@@ -75,6 +98,14 @@ type telegramBot struct {
 	// statemgmt is a in-memory key-value store for the bot state machine. See state-machine.go for details
 	statemgmt *cache.Cache
 
+	// keywordDetectors caches the keyword blocklist detector of each chat, as
+	// compiling the blocklist is expensive. See keywordDetector
+	keywordDetectors *cache.Cache
+
+	// userLanguages caches the last saved language of each user, so it's
+	// written in the database only when it changes. See saveUserLanguage
+	userLanguages *cache.Cache
+
 	// messageProcessedTotal is the counter of total processed messages
 	messageProcessedTotal prometheus.Counter
 
@@ -90,15 +121,22 @@ type telegramBot struct {
 	// userMessageCount is the message count per user
 	userMessageCount *prometheus.CounterVec
 
-	/*casDatabaseDownloadTime  prometheus.Gauge
-	casDatabaseSize          prometheus.Gauge*/
-
 	// casDatabaseMatch is the total number of matches for CAS
 	casDatabaseMatch prometheus.Counter
 
+	// banListMatchTotal is the number of matches per ban list
+	banListMatchTotal *prometheus.CounterVec
+
+	// keywordMatchTotal is the number of keyword blocklist matches per rule
+	// position in the chat blocklist
+	keywordMatchTotal *prometheus.CounterVec
+
 	// botCommandsRequestsTotal is the number of requests per command
 	botCommandsRequestsTotal *prometheus.CounterVec
 
+	// schedulerJobsTotal is the number of executed scheduled jobs per kind and result
+	schedulerJobsTotal *prometheus.CounterVec
+
 	// botReplyLatency is the latency of reply of the bot
 	botReplyLatency prometheus.Histogram
 }
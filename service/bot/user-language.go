@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// defaultLanguage is the language of messages sent to users whose language is
+// unknown (and to channels).
+const defaultLanguage = "en"
+
+// userLanguageTTL is how long the last saved language of a user is cached.
+const userLanguageTTL = 1 * time.Hour
+
+// userLanguage returns the language of the given user, as saved when the user
+// wrote to the bot in private, or defaultLanguage if it is unknown.
+func (bot *telegramBot) userLanguage(userID int64) string {
+	lang, err := bot.db.GetUserLanguage(userID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("userid", userID).Warn("Failed to get user language")
+	}
+	if lang == "" {
+		return defaultLanguage
+	}
+	return lang
+}
+
+// saveUserLanguage saves the given language of the given user, if it changed
+// since the last time. Errors are logged.
+func (bot *telegramBot) saveUserLanguage(userID int64, lang string) {
+	key := strconv.FormatInt(userID, 10)
+	if cached, found := bot.userLanguages.Get(key); found && cached.(string) == lang {
+		return
+	}
+
+	saved, err := bot.db.GetUserLanguage(userID)
+	if err != nil {
+		bot.logger.WithError(err).WithField("userid", userID).Warn("Failed to get user language")
+		return
+	}
+	if saved != lang {
+		if err := bot.db.SetUserLanguage(userID, lang); err != nil {
+			bot.logger.WithError(err).WithField("userid", userID).Warn("Failed to save user language")
+			return
+		}
+	}
+	bot.userLanguages.Set(key, lang, cache.DefaultExpiration)
+}
@@ -3,8 +3,13 @@ package bot
 import (
 	"crypto/sha1" // #nosec G505 not used for cryptographic purposes
 	"encoding/hex"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	tb "gopkg.in/telebot.v3"
 )
 
 // sha1string creates the HEX representation of the SHA1 of a string
@@ -36,3 +41,102 @@ func removeContents(dir string) error {
 	}
 	return nil
 }
+
+// containsString returns true if the given slice contains the given string.
+//
+// Time complexity: O(n) where "n" is the length of the slice.
+func containsString(list []string, str string) bool {
+	for _, v := range list {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a new slice without any occurrence of the given string.
+//
+// Time complexity: O(n) where "n" is the length of the slice.
+func removeString(list []string, str string) []string {
+	ret := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != str {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// userDisplayName returns the @username of the given user if any, otherwise the
+// full name.
+func userDisplayName(user *tb.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// Limits of mutes and bans durations: Telegram considers shorter or longer
+// restrictions as permanent.
+const (
+	minRestrictDuration = 30 * time.Second
+	maxRestrictDuration = 366 * 24 * time.Hour
+)
+
+var (
+	// errNotDuration is returned by parseDuration when the string is not a
+	// duration.
+	errNotDuration = errors.New("not a duration")
+
+	// errDurationRange is returned by parseDuration when the duration is
+	// outside minRestrictDuration and maxRestrictDuration.
+	errDurationRange = errors.New("duration out of range")
+)
+
+// parseDuration parses durations like "30m", "2d" or "1d12h". Supported units
+// are s, m, h, d (days) and w (weeks). It returns errNotDuration if s is not a
+// duration, and errDurationRange if the duration is shorter than
+// minRestrictDuration or longer than maxRestrictDuration.
+func parseDuration(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		's': time.Second,
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	var total time.Duration
+	var value int64
+	digits := 0
+	outOfRange := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			value = value*10 + int64(c-'0')
+			digits++
+			if digits > 6 {
+				return 0, errNotDuration
+			}
+			continue
+		}
+		unit, ok := units[c]
+		if !ok || digits == 0 {
+			return 0, errNotDuration
+		}
+		// Both the value and the total are not above maxRestrictDuration, so
+		// the sum cannot overflow.
+		if outOfRange || value > int64(maxRestrictDuration/unit) {
+			outOfRange = true
+		} else if total += time.Duration(value) * unit; total > maxRestrictDuration {
+			outOfRange = true
+		}
+		value, digits = 0, 0
+	}
+	if digits != 0 || s == "" {
+		return 0, errNotDuration
+	} else if outOfRange || total < minRestrictDuration {
+		return 0, errDurationRange
+	}
+	return total, nil
+}
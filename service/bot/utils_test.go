@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr error
+	}{
+		// In range.
+		{"30s", 30 * time.Second, nil},
+		{"30m", 30 * time.Minute, nil},
+		{"2h", 2 * time.Hour, nil},
+		{"2d", 2 * day, nil},
+		{"1w", 7 * day, nil},
+		{"366d", 366 * day, nil},
+
+		// Compound values.
+		{"1d12h", day + 12*time.Hour, nil},
+		{"1m30s", 90 * time.Second, nil},
+		{"1w1d1h1m1s", 8*day + time.Hour + time.Minute + time.Second, nil},
+		{"365d24h", 366 * day, nil},
+
+		// Below the minimum.
+		{"29s", 0, errDurationRange},
+		{"0m", 0, errDurationRange},
+		{"10s10s", 0, errDurationRange},
+
+		// Above the maximum.
+		{"367d", 0, errDurationRange},
+		{"53w", 0, errDurationRange},
+		{"366d1s", 0, errDurationRange},
+
+		// Values that would overflow time.Duration.
+		{"999999w", 0, errDurationRange},
+		{"200w200w200w", 0, errDurationRange},
+		{"9999999s", 0, errNotDuration},
+
+		// Not durations.
+		{"", 0, errNotDuration},
+		{"30", 0, errNotDuration},
+		{"m", 0, errNotDuration},
+		{"1y", 0, errNotDuration},
+		{"1.5h", 0, errNotDuration},
+		{"-1h", 0, errNotDuration},
+		{"1h ", 0, errNotDuration},
+		{"1hh", 0, errNotDuration},
+		{"spam", 0, errNotDuration},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDuration(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseDuration(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
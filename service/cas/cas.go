@@ -4,41 +4,95 @@ package cas
 
 import (
 	"net/http"
+	"time"
+
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/banlist"
 
 	"github.com/sirupsen/logrus"
 )
 
-// CAS is the object used to query and manage the database.
+// CAS is the object used to query and manage the database. It is the "cas" ban
+// list.
+//
+// Besides the database export, it can query the CAS API for a single user (see
+// banlist.Checker), so users banned after the last update are detected too.
+//
+// All methods are safe for concurrent use.
 type CAS interface {
-	// Load manually retrieve the datatabase from the combot website and loads
-	// it in memory, replacing the current database. It can be used when the
-	// auto-updater is disabled (see New function).
-	Load() error
+	banlist.BanListProvider
+	banlist.Checker
+}
 
-	// IsBanned returns true if the given Telegram's ID is present in the DB.
-	IsBanned(id int64) bool
+// Cache saves the results of the CAS API checks for a limited time.
+type Cache interface {
+	// GetCASCheck returns the saved result for the given user. found is false
+	// if there is no result, or if it is expired.
+	GetCASCheck(userID int64) (banned bool, found bool, err error)
 
-	// Close unloads the DB and stops the auto-updater worker, if started.
-	Close() error
+	// SetCASCheck saves the result for the given user for the given time.
+	SetCASCheck(userID int64, banned bool, ttl time.Duration) error
 }
 
-// cas is the concrete type that implements CAS interface.
+// cas is the concrete type that implements CAS: it wraps the database export
+// and adds the on demand checks.
 type cas struct {
-	c          *http.Client
-	db         map[int64]int8
-	logger     logrus.FieldLogger
-	workerStop bool // Used to stop the worker (if started).
+	banlist.BanListProvider
+
+	checkURL string
+	c        *http.Client
+	cache    Cache
+	breaker  breaker
+	logger   logrus.FieldLogger
 }
 
-// IsBanned returns true if the given Telegram's ID is present in the DB.
-func (cas *cas) IsBanned(uid int64) bool {
-	_, found := cas.db[uid]
-	return found
+// IsBanned returns true if the given Telegram's ID is present in the database,
+// or if a previous check (see Check) found it in the CAS API. It never queries
+// the CAS API.
+func (c *cas) IsBanned(id int64) bool {
+	if c.BanListProvider.IsBanned(id) {
+		return true
+	}
+	banned, _ := c.cached(id)
+	return banned
+}
+
+// Check is like IsBanned, but it queries the CAS API if there is no result in
+// cache. If the API is not available, it returns the same as IsBanned.
+func (c *cas) Check(id int64) bool {
+	if c.BanListProvider.IsBanned(id) {
+		return true
+	}
+	if banned, found := c.cached(id); found || c.checkURL == "" {
+		return banned
+	}
+
+	banned, err := c.check(id)
+	if err != nil {
+		// Errors are logged by check.
+		return false
+	}
+	if c.cache != nil {
+		ttl := notBannedTTL
+		if banned {
+			ttl = bannedTTL
+		}
+		if err := c.cache.SetCASCheck(id, banned, ttl); err != nil {
+			c.logger.WithError(err).Warning("Cannot save CAS check result")
+		}
+	}
+	return banned
 }
 
-// Close unloads the DB and stops the auto-updater worker, if started.
-func (cas *cas) Close() error {
-	cas.workerStop = true
-	cas.db = make(map[int64]int8)
-	return nil
+// cached returns the result of a previous check of the given user in cache.
+// found is false when there is no cache, or no result for the user.
+func (c *cas) cached(id int64) (banned bool, found bool) {
+	if c.cache == nil {
+		return false, false
+	}
+	banned, found, err := c.cache.GetCASCheck(id)
+	if err != nil {
+		c.logger.WithError(err).Warning("Cannot get CAS check result")
+		return false, false
+	}
+	return banned, found
 }
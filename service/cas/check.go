@@ -0,0 +1,139 @@
+package cas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("CAS check error: too many failures, check disabled temporarily")
+
+const (
+	// bannedTTL is how long a positive check result is cached. Bans are rarely
+	// revoked, and the user is found in the export after the next update
+	// anyway.
+	bannedTTL = 24 * time.Hour
+
+	// notBannedTTL is how long a negative check result is cached.
+	notBannedTTL = 1 * time.Hour
+
+	// checkTimeout is the timeout of a single check. Checks are done while
+	// processing a message, so they must be fast.
+	checkTimeout = 5 * time.Second
+)
+
+// checkResponse is the response of the CAS API check endpoint. "ok" is true
+// only if the user is banned.
+type checkResponse struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// check queries the CAS API for the given user. It returns ErrCircuitOpen
+// without any query if the API failed too many times recently.
+func (c *cas) check(id int64) (bool, error) {
+	if !c.breaker.Allow() {
+		return false, ErrCircuitOpen
+	}
+
+	banned, err := c.query(id)
+	if err != nil {
+		if c.breaker.Failure() {
+			c.logger.WithError(err).Warningf("CAS check failed too many times, disabled for %s", c.breaker.cooldown)
+		} else {
+			c.logger.WithError(err).Warning("CAS check failed")
+		}
+		return false, err
+	}
+	c.breaker.Success()
+	return banned, nil
+}
+
+// query does the HTTP request to the check endpoint, adding the user ID as the
+// "user_id" query parameter.
+func (c *cas) query(id int64) (bool, error) {
+	u, err := url.Parse(c.checkURL)
+	if err != nil {
+		return false, err
+	}
+	q := u.Query()
+	q.Set("user_id", strconv.FormatInt(id, 10))
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("CAS check error: HTTP status %d", resp.StatusCode)
+	}
+
+	var result checkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("CAS check error: %w", err)
+	}
+	return result.Ok, nil
+}
+
+// breaker is a circuit breaker: after maxFailures consecutive failures, the
+// circuit opens and Allow returns false for cooldown. Then, a single request is
+// allowed (half-open circuit): if it succeeds the circuit closes, otherwise it
+// opens again.
+type breaker struct {
+	maxFailures int
+	cooldown    time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// Allow returns true if a request can be done.
+func (b *breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.maxFailures {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Success records a successful request, closing the circuit.
+func (b *breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed request. It returns true if the circuit opens.
+func (b *breaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures < b.maxFailures {
+		return false
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	return true
+}
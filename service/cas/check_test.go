@@ -0,0 +1,209 @@
+package cas
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryCache is a Cache in memory. TTLs are ignored.
+type memoryCache struct {
+	mu      sync.Mutex
+	results map[int64]bool
+}
+
+func (m *memoryCache) GetCASCheck(userID int64) (bool, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	banned, found := m.results[userID]
+	return banned, found, nil
+}
+
+func (m *memoryCache) SetCASCheck(userID int64, banned bool, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.results == nil {
+		m.results = make(map[int64]bool)
+	}
+	m.results[userID] = banned
+	return nil
+}
+
+// checkServer is a fake CAS API check endpoint, that counts the requests.
+type checkServer struct {
+	*httptest.Server
+	requests int32
+	handler  atomic.Value // http.HandlerFunc
+}
+
+func newCheckServer(t *testing.T, handler http.HandlerFunc) *checkServer {
+	t.Helper()
+	s := &checkServer{}
+	s.setHandler(handler)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		s.handler.Load().(http.HandlerFunc)(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *checkServer) setHandler(handler http.HandlerFunc) {
+	s.handler.Store(handler)
+}
+
+func (s *checkServer) count() int {
+	return int(atomic.LoadInt32(&s.requests))
+}
+
+// newTestCAS returns a CAS without database export, that checks users with the
+// given server.
+func newTestCAS(t *testing.T, s *checkServer, cache Cache) *cas {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	c, err := New(Options{
+		Logger:   logger,
+		Client:   &http.Client{Timeout: 200 * time.Millisecond},
+		CheckURL: s.URL,
+		Cache:    cache,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c.(*cas)
+}
+
+// respond returns a handler that replies with the given status and body.
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		banned  bool
+		wantErr bool
+	}{
+		{"banned", respond(http.StatusOK, `{"ok":true,"result":{"offenses":1}}`), true, false},
+		{"not banned", respond(http.StatusOK, `{"ok":false,"description":"Record not found."}`), false, false},
+		{"server error", respond(http.StatusInternalServerError, `{"ok":true}`), false, true},
+		{"malformed JSON", respond(http.StatusOK, `<html>`), false, true},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+		}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID atomic.Value
+			s := newCheckServer(t, func(w http.ResponseWriter, r *http.Request) {
+				userID.Store(r.URL.Query().Get("user_id"))
+				tt.handler(w, r)
+			})
+			cache := &memoryCache{}
+			c := newTestCAS(t, s, cache)
+
+			banned, err := c.check(42)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check error = %v, want error %v", err, tt.wantErr)
+			}
+			if banned != tt.banned {
+				t.Errorf("check = %v, want %v", banned, tt.banned)
+			}
+			if got, _ := userID.Load().(string); got != "42" {
+				t.Errorf("user_id = %q, want \"42\"", got)
+			}
+
+			// Only successful checks are cached.
+			if got := c.Check(42); got != tt.banned {
+				t.Errorf("Check = %v, want %v", got, tt.banned)
+			}
+			if got := c.IsBanned(42); got != tt.banned {
+				t.Errorf("IsBanned = %v, want %v", got, tt.banned)
+			}
+			_, found, _ := cache.GetCASCheck(42)
+			if found == tt.wantErr {
+				t.Errorf("cached = %v, want %v", found, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	s := newCheckServer(t, respond(http.StatusOK, `{"ok":true}`))
+	c := newTestCAS(t, s, &memoryCache{})
+
+	if c.IsBanned(42) {
+		t.Errorf("IsBanned before Check = true, want false")
+	}
+	if s.count() != 0 {
+		t.Errorf("IsBanned did %d requests, want 0", s.count())
+	}
+	for i := 0; i < 3; i++ {
+		if !c.Check(42) {
+			t.Errorf("Check #%d = false, want true", i)
+		}
+	}
+	if s.count() != 1 {
+		t.Errorf("Check did %d requests, want 1", s.count())
+	}
+	if !c.IsBanned(42) {
+		t.Errorf("IsBanned after Check = false, want true")
+	}
+}
+
+func TestCheckBreaker(t *testing.T) {
+	s := newCheckServer(t, respond(http.StatusBadGateway, ""))
+	c := newTestCAS(t, s, nil)
+	c.breaker.cooldown = 100 * time.Millisecond
+
+	// The circuit opens after maxCheckFailures failures.
+	for i := 0; i < maxCheckFailures; i++ {
+		if _, err := c.check(42); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("check #%d error = %v, want HTTP error", i, err)
+		}
+	}
+	if _, err := c.check(42); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("check with open circuit error = %v, want ErrCircuitOpen", err)
+	}
+	if s.count() != maxCheckFailures {
+		t.Fatalf("requests = %d, want %d", s.count(), maxCheckFailures)
+	}
+
+	// After the cooldown, a failed probe opens the circuit again.
+	time.Sleep(c.breaker.cooldown)
+	if _, err := c.check(42); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe error = %v, want HTTP error", err)
+	}
+	if _, err := c.check(42); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("check after failed probe error = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes the circuit.
+	time.Sleep(c.breaker.cooldown)
+	s.setHandler(respond(http.StatusOK, `{"ok":false}`))
+	for i := 0; i < 3; i++ {
+		if _, err := c.check(42); err != nil {
+			t.Fatalf("check #%d after successful probe error = %v, want nil", i, err)
+		}
+	}
+	if want := maxCheckFailures + 4; s.count() != want {
+		t.Errorf("requests = %d, want %d", s.count(), want)
+	}
+}
@@ -4,26 +4,92 @@ import (
 	"net/http"
 	"time"
 
+	"gitlab.com/sapienzastudents/antispam-telegram-bot/service/banlist"
+
 	"github.com/sirupsen/logrus"
 )
 
+// Name is the name of the CAS ban list.
+const Name = "cas"
+
+// exportURL is the address of the CAS database export.
+const exportURL = "https://api.cas.chat/export.csv"
+
+// CheckURL is the default address of the CAS API check endpoint.
+const CheckURL = "https://api.cas.chat/check"
+
+const (
+	// maxCheckFailures is the number of consecutive check failures after which
+	// checks are disabled temporarily.
+	maxCheckFailures = 5
+
+	// checkCooldown is how long checks are disabled after too many failures.
+	checkCooldown = 5 * time.Minute
+)
+
+// Options are the options for New.
+type Options struct {
+	// AutoUpdate launches a goroutine that updates the database in background
+	AutoUpdate bool
+
+	// Logger is used for debug
+	Logger logrus.FieldLogger
+
+	// Client overrides the default HTTP client, if not nil
+	Client *http.Client
+
+	// Store saves the last good database, if not nil. The saved database is
+	// loaded at startup, unless File is set
+	Store banlist.Store
+
+	// File is a database file in CSV export format loaded at startup, if not
+	// empty (e.g. for deployments without Internet access)
+	File string
+
+	// CheckURL is the address of the check endpoint (see banlist.Checker),
+	// queried with the "user_id" parameter. If empty, single users are not
+	// checked
+	CheckURL string
+
+	// Cache saves the check results, if not nil. Without a cache, IsBanned
+	// ignores the check results, and every check queries the endpoint
+	Cache Cache
+}
+
 // New returns a CAS instance.
 //
-// If autoupdate is true, it launches a goroutine that updates the database in
-// background and the given logger is used for debug.
-//
-// If client is not nil it will be used to override default HTTP client.
-func New(autoupdate bool, logger logrus.FieldLogger, client *http.Client) (CAS, error) {
-	if client == nil {
-		client = &http.Client{Timeout: 1 * time.Minute}
+// The initial database is loaded from the file or from the store in options,
+// so it's available before the first download.
+func New(opts Options) (CAS, error) {
+	list, err := banlist.New(banlist.Options{
+		Name:       Name,
+		URL:        exportURL,
+		Format:     banlist.FormatLines,
+		Interval:   1 * time.Hour,
+		NonEmpty:   true,
+		AutoUpdate: opts.AutoUpdate,
+		Logger:     opts.Logger,
+		Client:     opts.Client,
+		Store:      opts.Store,
+		File:       opts.File,
+	})
+	if err != nil {
+		return nil, err
 	}
-	c := cas{
-		c:      client,
-		logger: logger,
-		db:     make(map[int64]int8),
+
+	ret := &cas{
+		BanListProvider: list,
+		checkURL:        opts.CheckURL,
+		c:               opts.Client,
+		cache:           opts.Cache,
+		breaker: breaker{
+			maxFailures: maxCheckFailures,
+			cooldown:    checkCooldown,
+		},
+		logger: opts.Logger.WithField("banlist", Name),
 	}
-	if autoupdate {
-		go c.worker()
+	if ret.c == nil {
+		ret.c = &http.Client{Timeout: checkTimeout}
 	}
-	return &c, nil
+	return ret, nil
 }
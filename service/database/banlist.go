@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SetBanListSnapshot saves the given ban list, one ID per line, and the time of
+// its download. It implements banlist.Store.
+//
+// The list is in the "banlist:NAME:snapshot" key, and the time (unix time in
+// milliseconds) in "banlist:NAME:updated".
+func (db *Database) SetBanListSnapshot(name string, data []byte, updated time.Time) error {
+	key := fmt.Sprintf("banlist:%s:snapshot", name)
+	pipe := db.conn.TxPipeline()
+	pipe.Set(context.TODO(), key, data, 0)
+	pipe.Set(context.TODO(), fmt.Sprintf("banlist:%s:updated", name), unixMilli(updated), 0)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return fmt.Errorf("on \"SET %s\": %w", key, err)
+	}
+	return nil
+}
+
+// GetBanListSnapshot returns the saved ban list and the time of its download.
+// It returns nil data if the list was not saved. It implements banlist.Store.
+func (db *Database) GetBanListSnapshot(name string) ([]byte, time.Time, error) {
+	key := fmt.Sprintf("banlist:%s:snapshot", name)
+	pipe := db.conn.TxPipeline()
+	data := pipe.Get(context.TODO(), key)
+	updated := pipe.Get(context.TODO(), fmt.Sprintf("banlist:%s:updated", name))
+	_, err := pipe.Exec(context.TODO())
+	if err == redis.Nil {
+		return nil, time.Time{}, nil
+	} else if err != nil {
+		return nil, time.Time{}, fmt.Errorf("on \"GET %s\": %w", key, err)
+	}
+
+	ms, err := strconv.ParseInt(updated.Val(), 10, 64)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error decoding update time of %q: %w", key, err)
+	}
+	raw, err := data.Bytes()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("on \"GET %s\": %w", key, err)
+	}
+	return raw, time.Unix(0, ms*int64(time.Millisecond)), nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// bayesClass returns the name of the class in Redis keys and values.
+func bayesClass(spam bool) string {
+	if spam {
+		return "spam"
+	}
+	return "ham"
+}
+
+// BayesCounts returns the number of trained spam and ham documents, and the
+// number of spam and ham documents containing each one of the given tokens.
+//
+// The model is shared by all chats: document counts are in the "bayes:docs"
+// HSET, and token counts in "bayes:tokens:spam" and "bayes:tokens:ham" HSETs.
+func (db *Database) BayesCounts(tokens []string) (int64, int64, []int64, []int64, error) {
+	pipe := db.conn.Pipeline()
+	docs := pipe.HMGet(context.TODO(), "bayes:docs", "spam", "ham")
+	var spamTokens, hamTokens *redis.SliceCmd
+	if len(tokens) > 0 {
+		spamTokens = pipe.HMGet(context.TODO(), "bayes:tokens:spam", tokens...)
+		hamTokens = pipe.HMGet(context.TODO(), "bayes:tokens:ham", tokens...)
+	}
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return 0, 0, nil, nil, fmt.Errorf("on \"HMGET bayes\": %w", err)
+	}
+
+	docCounts, err := parseCounts(docs.Val())
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	spam, ham := make([]int64, len(tokens)), make([]int64, len(tokens))
+	if len(tokens) > 0 {
+		if spam, err = parseCounts(spamTokens.Val()); err != nil {
+			return 0, 0, nil, nil, err
+		}
+		if ham, err = parseCounts(hamTokens.Val()); err != nil {
+			return 0, 0, nil, nil, err
+		}
+	}
+	return docCounts[0], docCounts[1], spam, ham, nil
+}
+
+// parseCounts converts the values returned by HMGET into integers. Missing
+// fields are zero.
+func parseCounts(values []interface{}) ([]int64, error) {
+	ret := make([]int64, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing bayes count %q: %w", s, err)
+		}
+		ret[i] = n
+	}
+	return ret, nil
+}
+
+// bayesLearnScript atomically adds a document to a class: ARGV[1] is the
+// document hash, ARGV[2] the class ("spam" or "ham"), and the other arguments
+// are the tokens. If the document (in the KEYS[1] HSET) was added to the other
+// class, it is removed from there first. KEYS[2] is the document count HSET,
+// KEYS[3] and KEYS[4] are the spam and ham token count HSETs.
+var bayesLearnScript = redis.NewScript(`
+local old = redis.call("HGET", KEYS[1], ARGV[1])
+if old == ARGV[2] then
+	return 0
+end
+local function train(class, delta)
+	local tokens = KEYS[4]
+	if class == "spam" then
+		tokens = KEYS[3]
+	end
+	redis.call("HINCRBY", KEYS[2], class, delta)
+	for i = 3, #ARGV do
+		redis.call("HINCRBY", tokens, ARGV[i], delta)
+	end
+end
+if old then
+	train(old, -1)
+end
+train(ARGV[2], 1)
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// BayesLearn adds the document with the given hash and tokens to the given
+// class (spam or ham), removing it from the other class if needed. It is
+// atomic, so concurrent calls for the same document count it once.
+//
+// Trained documents are in the "bayes:trained" HSET (the field is the hash,
+// the value is the class).
+func (db *Database) BayesLearn(hash string, spam bool, tokens []string) error {
+	keys := []string{"bayes:trained", "bayes:docs", "bayes:tokens:spam", "bayes:tokens:ham"}
+	args := make([]interface{}, 0, len(tokens)+2)
+	args = append(args, hash, bayesClass(spam))
+	for _, token := range tokens {
+		args = append(args, token)
+	}
+	if err := bayesLearnScript.Run(context.TODO(), db.conn, keys, args...).Err(); err != nil {
+		return fmt.Errorf("on learning bayes document: %w", err)
+	}
+	return nil
+}
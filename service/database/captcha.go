@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrChallengeNotFound = errors.New("challenge not found")
+)
+
+// Challenge is a pending join CAPTCHA challenge.
+type Challenge struct {
+	// ChatID is the chat where the user joined
+	ChatID int64 `json:"chat_id"`
+
+	// UserID is the user that must answer
+	UserID int64 `json:"user_id"`
+
+	// MessageID is the ID of the message with the challenge
+	MessageID int `json:"message_id"`
+
+	// Answer is the correct answer
+	Answer string `json:"answer"`
+
+	// Deadline is the unix time after which the challenge fails
+	Deadline int64 `json:"deadline"`
+}
+
+// AddChallenge saves the given pending challenge, replacing any previous
+// challenge for the same user in the same chat.
+//
+// Challenges are serialized as JSON inside the "captcha" HSET (the field name is
+// "chat ID:user ID").
+func (db *Database) AddChallenge(challenge Challenge) error {
+	jsonb, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	err = db.conn.HSet(context.TODO(), "captcha", chatUserMember(challenge.ChatID, challenge.UserID), jsonb).Err()
+	if err != nil {
+		return fmt.Errorf("on \"HSET captcha\": %w", err)
+	}
+	return nil
+}
+
+// GetChallenge returns the pending challenge for the given user in the given
+// chat. If there is no challenge, it returns ErrChallengeNotFound.
+func (db *Database) GetChallenge(chatID int64, userID int64) (Challenge, error) {
+	var challenge Challenge
+	jsonb, err := db.conn.HGet(context.TODO(), "captcha", chatUserMember(chatID, userID)).Result()
+	if err == redis.Nil {
+		return challenge, ErrChallengeNotFound
+	} else if err != nil {
+		return challenge, fmt.Errorf("on \"HGET captcha\": %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(jsonb), &challenge); err != nil {
+		return challenge, fmt.Errorf("error decoding challenge from JSON: %w", err)
+	}
+	return challenge, nil
+}
+
+// RemoveChallenge removes the pending challenge for the given user in the given
+// chat. It returns ErrChallengeNotFound if the challenge was already removed,
+// so only one caller acts on a challenge.
+func (db *Database) RemoveChallenge(chatID int64, userID int64) error {
+	removed, err := db.conn.HDel(context.TODO(), "captcha", chatUserMember(chatID, userID)).Result()
+	if err != nil {
+		return fmt.Errorf("on \"HDEL captcha\": %w", err)
+	}
+	if removed == 0 {
+		return ErrChallengeNotFound
+	}
+	return nil
+}
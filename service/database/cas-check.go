@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// casCheckKey returns the key of the CAS check result of the given user.
+func casCheckKey(userID int64) string {
+	return fmt.Sprintf("cas:check:%d", userID)
+}
+
+// GetCASCheck returns the saved CAS check result of the given user. found is
+// false if there is no result, or if it is expired. It implements cas.Cache.
+func (db *Database) GetCASCheck(userID int64) (banned bool, found bool, err error) {
+	key := casCheckKey(userID)
+	value, err := db.conn.Get(context.TODO(), key).Result()
+	if err == redis.Nil {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, fmt.Errorf("on \"GET %s\": %w", key, err)
+	}
+	return value == "1", true, nil
+}
+
+// SetCASCheck saves the CAS check result of the given user, expiring after the
+// given time. It implements cas.Cache.
+func (db *Database) SetCASCheck(userID int64, banned bool, ttl time.Duration) error {
+	key := casCheckKey(userID)
+	value := "0"
+	if banned {
+		value = "1"
+	}
+	if err := db.conn.Set(context.TODO(), key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("on \"SET %s\": %w", key, err)
+	}
+	return nil
+}
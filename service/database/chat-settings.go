@@ -61,6 +61,162 @@ type BotAction struct {
 	Delay uint `json:"delay"`
 }
 
+// DetectorSettings are the per-chat settings of an antispam detector.
+type DetectorSettings struct {
+	// Action is what the bot should do when the detector triggers
+	Action BotAction `json:"action"`
+
+	// Threshold is the score above which the detector triggers. Zero means the detector default
+	Threshold float64 `json:"threshold"`
+}
+
+// FloodSettings are the per-chat limits for the flood detection.
+type FloodSettings struct {
+	// Messages is the maximum number of messages that a user can send in Window. Zero means no limit
+	Messages uint `json:"messages"`
+
+	// Repeats is the maximum number of messages with the same content that a user can send in Window. Zero means no
+	// limit
+	Repeats uint `json:"repeats"`
+
+	// Window is the length of the sliding window, in seconds
+	Window uint `json:"window"`
+}
+
+// ProbationSettings are the per-chat settings for new members probation. While
+// in probation, new members cannot send media, links and forwards.
+type ProbationSettings struct {
+	// Enabled puts new members in probation when they join
+	Enabled bool `json:"enabled"`
+
+	// Duration is the probation length, in seconds. Zero means no time limit
+	Duration uint `json:"duration"`
+
+	// Messages is the number of clean messages after which the probation ends. Zero means no message limit
+	Messages uint `json:"messages"`
+}
+
+// CaptchaSettings are the per-chat settings for the join CAPTCHA.
+type CaptchaSettings struct {
+	// Enabled mutes new members when they join, until they answer a challenge
+	Enabled bool `json:"enabled"`
+
+	// Timeout is the time to answer, in seconds. Zero means the default (see the bot)
+	Timeout uint `json:"timeout"`
+}
+
+// Content types in ContentPolicy rules.
+const (
+	// ContentForwardChannel are messages forwarded from channels and groups outside the network
+	ContentForwardChannel = "forward_channel"
+
+	// ContentForwardUser are messages forwarded from other users
+	ContentForwardUser = "forward_user"
+
+	// ContentViaBot are inline results sent via bots
+	ContentViaBot = "via_bot"
+
+	// ContentContact are contacts
+	ContentContact = "contact"
+
+	// ContentLocation are locations and venues
+	ContentLocation = "location"
+
+	// ContentPoll are polls
+	ContentPoll = "poll"
+
+	// ContentDice are dice and games
+	ContentDice = "dice"
+
+	// ContentVoiceNote are voice messages and video notes
+	ContentVoiceNote = "voice_note"
+)
+
+// Content type rules in ContentPolicy.
+const (
+	ContentAllow       = 0
+	ContentBlock       = 1
+	ContentBlockRecent = 2
+)
+
+// ContentPolicy is the per-chat policy for content types (forwards, polls,
+// etc.).
+type ContentPolicy struct {
+	// Rules is the rule for each content type (ContentAllow, ContentBlock or ContentBlockRecent). Content types not in
+	// the map are allowed
+	Rules map[string]int `json:"rules"`
+
+	// Action is what the bot should do when a message breaks a rule
+	Action BotAction `json:"action"`
+
+	// RecentWindow is the time in seconds after joining in which a member is recent, for ContentBlockRecent rules.
+	// Zero means the bot default
+	RecentWindow uint `json:"recent_window"`
+}
+
+// Permission profiles for QuietHours.
+const (
+	// QuietTextOnly allows only text messages
+	QuietTextOnly = 0
+
+	// QuietReadOnly does not allow any message
+	QuietReadOnly = 1
+)
+
+// QuietHours is a per-chat weekly schedule: while active, the default
+// permissions of the chat are restricted by a permission profile.
+type QuietHours struct {
+	// Enabled activates the schedule
+	Enabled bool `json:"enabled"`
+
+	// Days are the weekdays in which the schedule starts, as a bit mask where bit N is time.Weekday(N)
+	Days uint8 `json:"days"`
+
+	// Start is the hour of the day (0-23) in which the schedule starts
+	Start uint `json:"start"`
+
+	// End is the hour of the day (0-23) in which the schedule ends. If less or equal than Start, the schedule ends on
+	// the next day
+	End uint `json:"end"`
+
+	// TimeZone is the IANA name of the time zone of Start and End. Empty means UTC
+	TimeZone string `json:"time_zone"`
+
+	// Profile is the permission profile (QuietTextOnly or QuietReadOnly)
+	Profile int `json:"profile"`
+
+	// Announce sends a message in the chat when the schedule starts and ends
+	Announce bool `json:"announce"`
+}
+
+// RaidSettings are the per-chat settings for the raid detection. A raid is a
+// burst of joins: when detected, the chat goes in lockdown.
+type RaidSettings struct {
+	// Joins is the number of joins in Window above which a raid is detected. Zero disables the detection
+	Joins uint `json:"joins"`
+
+	// Window is the length of the sliding window, in seconds
+	Window uint `json:"window"`
+
+	// Lockdown is the lockdown duration, in seconds. Zero means the bot default
+	Lockdown uint `json:"lockdown"`
+}
+
+// StrikeSettings are the per-chat settings for the escalating strike system.
+// When enabled, each violation adds a strike to the user, and the action is
+// taken from Ladder instead of the one configured for the violation.
+type StrikeSettings struct {
+	// Enabled makes violations add strikes and follow the Ladder
+	Enabled bool `json:"enabled"`
+
+	// Decay is the time in seconds after which strikes are forgotten, if the user has no new strikes. Zero means never
+	Decay uint `json:"decay"`
+
+	// Ladder is the action for each strike: the first item is for the first strike, and so on. Strikes beyond the
+	// ladder use the last item. Empty means the bot default
+	Ladder []BotAction `json:"ladder"`
+}
+
 type ChatSettings struct {
 	// BotEnabled represent whether the bot is enabled for this chat. Enabling the bot will enable automatic actions
 	// (such as antispam or CAS blacklist) and will enable some commands.
@@ -80,17 +236,84 @@ type ChatSettings struct {
 	// chatroom
 	OnJoinArabic BotAction `json:"on_join_arabic"`
 
-	// OnMessageChinese is the action that the bot should do if it detects a message in Chinese
+	// OnJoinNameLink is the action that the bot should do if a user with links or @mentions in the name joins the
+	// chatroom
+	OnJoinNameLink BotAction `json:"on_join_name_link"`
+
+	// OnJoinSymbolName is the action that the bot should do if a user whose name has no letters (e.g. only emojis)
+	// joins the chatroom
+	OnJoinSymbolName BotAction `json:"on_join_symbol_name"`
+
+	// OnJoinNoUsername is the action that the bot should do if a user without username joins the chatroom
+	OnJoinNoUsername BotAction `json:"on_join_no_username"`
+
+	// OnJoinBioLink is the action that the bot should do if a user with links or @mentions in the bio joins the
+	// chatroom
+	OnJoinBioLink BotAction `json:"on_join_bio_link"`
+
+	// OnMessageChinese is the action that the bot should do if it detects a message in Chinese.
+	//
+	// Deprecated: it is migrated into Detectors["chinese"] when settings are loaded.
 	OnMessageChinese BotAction `json:"on_message_chinese"`
 
-	// OnMessageArabic is the action that the bot should do if it detects a message in Arabic
+	// OnMessageArabic is the action that the bot should do if it detects a message in Arabic.
+	//
+	// Deprecated: it is migrated into Detectors["arabic"] when settings are loaded.
 	OnMessageArabic BotAction `json:"on_message_arabic"`
 
-	//OnMessageSpam    BotAction `json:"on_message_spam"`
+	// Scripts is the list of Unicode script names (see unicode.Scripts) that the "script" detector blocks in messages.
+	// The action and the threshold are in Detectors["script"]
+	Scripts []string `json:"scripts"`
+
+	// LinkAllow is the list of domains (and their sub-domains) that are always allowed in messages by the "links"
+	// detector
+	LinkAllow []string `json:"link_allow"`
+
+	// LinkDeny is the list of domains (and their sub-domains) that are refused in messages by the "links" detector
+	LinkDeny []string `json:"link_deny"`
+
+	// LinkBlockAll makes the "links" detector refuse any link from non-admins, except the ones in LinkAllow
+	LinkBlockAll bool `json:"link_block_all"`
+
+	// Detectors are the settings of each antispam detector, indexed by detector name. Detectors not in the map are
+	// disabled
+	Detectors map[string]DetectorSettings `json:"detectors"`
+
+	// OnFlood is the action that the bot should do if a user exceeds the limits in Flood
+	OnFlood BotAction `json:"on_flood"`
+
+	// Flood are the limits for the flood detection
+	Flood FloodSettings `json:"flood"`
+
+	// OnDuplicate is the action that the bot should do if a user sends the same message in too many chats of the
+	// network (see bot options)
+	OnDuplicate BotAction `json:"on_duplicate"`
+
+	// Probation is the policy for new members
+	Probation ProbationSettings `json:"probation"`
+
+	// Captcha is the join verification policy for new members
+	Captcha CaptchaSettings `json:"captcha"`
+
+	// QuietHours is the schedule of restricted permissions
+	QuietHours QuietHours `json:"quiet_hours"`
+
+	// Raid is the policy for join bursts
+	Raid RaidSettings `json:"raid"`
+
+	// Content is the policy for content types
+	Content ContentPolicy `json:"content"`
+
+	// Strikes is the escalation policy for repeated violations
+	Strikes StrikeSettings `json:"strikes"`
 
 	// OnBlacklistCAS is the action that the bot should do if it detects a message from a CAS-banned user
 	OnBlacklistCAS BotAction `json:"on_blacklist_cas"`
 
+	// OnBanList is the action that the bot should do if it detects a message from a user in a ban list, by ban list
+	// name. The "cas" ban list uses OnBlacklistCAS
+	OnBanList map[string]BotAction `json:"on_ban_list"`
+
 	// ChatAdmins is the list of chat admins (regardless of their permissions in the chat)
 	ChatAdmins ChatAdminList `json:"chat_admins"`
 
@@ -107,6 +330,63 @@ type ChatSettings struct {
 	LogChannel int64 `json:"log_channel"`
 }
 
+// Detector returns the settings for the given detector name. If the detector
+// is not configured, the returned settings have ActionNone as action.
+func (s *ChatSettings) Detector(name string) DetectorSettings {
+	return s.Detectors[name]
+}
+
+// SetDetector saves the settings for the given detector name.
+func (s *ChatSettings) SetDetector(name string, detector DetectorSettings) {
+	if s.Detectors == nil {
+		s.Detectors = make(map[string]DetectorSettings)
+	}
+	s.Detectors[name] = detector
+}
+
+// BanListAction returns the action for users in the given ban list. If the ban
+// list is not configured, the returned action is ActionNone.
+//
+// The "cas" ban list action is stored in OnBlacklistCAS.
+func (s *ChatSettings) BanListAction(name string) BotAction {
+	if name == "cas" {
+		return s.OnBlacklistCAS
+	}
+	return s.OnBanList[name]
+}
+
+// SetBanListAction saves the action for users in the given ban list.
+func (s *ChatSettings) SetBanListAction(name string, action BotAction) {
+	if name == "cas" {
+		s.OnBlacklistCAS = action
+		return
+	}
+	if s.OnBanList == nil {
+		s.OnBanList = make(map[string]BotAction)
+	}
+	s.OnBanList[name] = action
+}
+
+// migrateOldDetectors moves the old per-language message actions into the
+// Detectors map.
+//
+// TODO: This method can be removed in the future.
+func (s *ChatSettings) migrateOldDetectors() {
+	old := map[string]*BotAction{
+		"chinese": &s.OnMessageChinese,
+		"arabic":  &s.OnMessageArabic,
+	}
+	for name, action := range old {
+		if action.Action == ActionNone {
+			continue
+		}
+		if _, found := s.Detectors[name]; !found {
+			s.SetDetector(name, DetectorSettings{Action: *action})
+		}
+		*action = BotAction{}
+	}
+}
+
 // GetChatSettings returns the chat settings of the bot for the given chat ID.
 func (db *Database) GetChatSettings(chatID int64) (ChatSettings, error) {
 	// GetChatSettings deserializes the JSON with the ChatSettings structure
@@ -122,6 +402,7 @@ func (db *Database) GetChatSettings(chatID int64) (ChatSettings, error) {
 	if err = json.Unmarshal([]byte(jsonb), &settings); err != nil {
 		return settings, fmt.Errorf("error decoding chat settings from JSON: %w", err)
 	}
+	settings.migrateOldDetectors()
 	return settings, nil
 }
 
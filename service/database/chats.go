@@ -106,6 +106,9 @@ func (db *Database) DeleteChat(id int64) error {
 	if err := db.conn.HDel(context.TODO(), "settings", sid).Err(); err != nil {
 		return fmt.Errorf("on removing chat's settings from \"settings\": %w", err)
 	}
+	if err := db.conn.HDel(context.TODO(), "keywords", sid).Err(); err != nil {
+		return fmt.Errorf("on removing chat's keywords from \"keywords\": %w", err)
+	}
 
 	return nil
 }
@@ -170,3 +173,12 @@ func (db *Database) ListMyChats() ([]*tb.Chat, error) {
 
 	return chats, nil
 }
+
+// IsChatInNetwork returns true if the given chat ID is a tracked chat.
+func (db *Database) IsChatInNetwork(id int64) (bool, error) {
+	ret, err := db.conn.SIsMember(context.TODO(), "chats", strconv.FormatInt(id, 10)).Result()
+	if err != nil {
+		return false, fmt.Errorf("on \"SISMEMBER chats\": %w", err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ChatMessage identifies a message in a chat.
+type ChatMessage struct {
+	ChatID    int64
+	MessageID int
+}
+
+// AddNetworkFingerprint records that the given user sent a message with the
+// given fingerprint in the given chat, and returns all messages with the same
+// fingerprint sent by that user in any chat in the last window (including the
+// given one). Records expire after the window.
+func (db *Database) AddNetworkFingerprint(userID int64, fingerprint string, chatID int64, messageID int, window time.Duration) ([]ChatMessage, error) {
+	key := fmt.Sprintf("duplicates:%d:%s", userID, fingerprint)
+	now := time.Now()
+	min := strconv.FormatInt(now.Add(-window).UnixNano()/int64(time.Millisecond), 10)
+
+	pipe := db.conn.TxPipeline()
+	pipe.ZRemRangeByScore(context.TODO(), key, "-inf", "("+min)
+	pipe.ZAdd(context.TODO(), key, &redis.Z{
+		Score:  float64(now.UnixNano() / int64(time.Millisecond)),
+		Member: strconv.FormatInt(chatID, 10) + ":" + strconv.Itoa(messageID),
+	})
+	members := pipe.ZRange(context.TODO(), key, 0, -1)
+	pipe.Expire(context.TODO(), key, window)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return nil, fmt.Errorf("on recording %q: %w", key, err)
+	}
+
+	ret := make([]ChatMessage, 0, len(members.Val()))
+	for _, member := range members.Val() {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		chatID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		messageID, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		ret = append(ret, ChatMessage{ChatID: chatID, MessageID: messageID})
+	}
+	return ret, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// countInWindow adds the given member to the sorted set at key, using the
+// current time as score, and returns the number of members added in the last
+// window. Older members are removed, and the key expires after the window, so
+// the sliding window survives reboots and is shared between replicas.
+func (db *Database) countInWindow(key string, member string, window time.Duration) (int64, error) {
+	now := time.Now()
+	min := strconv.FormatInt(now.Add(-window).UnixNano()/int64(time.Millisecond), 10)
+
+	pipe := db.conn.TxPipeline()
+	pipe.ZRemRangeByScore(context.TODO(), key, "-inf", "("+min)
+	pipe.ZAdd(context.TODO(), key, &redis.Z{
+		Score:  float64(now.UnixNano() / int64(time.Millisecond)),
+		Member: member,
+	})
+	count := pipe.ZCard(context.TODO(), key)
+	pipe.Expire(context.TODO(), key, window)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return 0, fmt.Errorf("on counting %q: %w", key, err)
+	}
+	return count.Val(), nil
+}
+
+// CountFloodMessage records the given message of the given user in the given
+// chat, and returns the number of messages sent by that user in that chat in
+// the last window.
+func (db *Database) CountFloodMessage(chatID int64, userID int64, messageID int, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("flood:%d:%d", chatID, userID)
+	return db.countInWindow(key, strconv.Itoa(messageID), window)
+}
+
+// CountFloodRepeat is like CountFloodMessage, but it counts only messages with
+// the given fingerprint (i.e. messages with the same content).
+func (db *Database) CountFloodRepeat(chatID int64, userID int64, fingerprint string, messageID int, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("flood:%d:%d:%s", chatID, userID, fingerprint)
+	return db.countInWindow(key, strconv.Itoa(messageID), window)
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Job is a delayed action, executed by the bot scheduler.
+type Job struct {
+	// ID is the unique ID of the job, assigned by ScheduleJob
+	ID string `json:"id"`
+
+	// Kind is what the scheduler should do (the meaning is up to the bot)
+	Kind string `json:"kind"`
+
+	// ChatID is the chat where the job acts
+	ChatID int64 `json:"chat_id"`
+
+	// UserID is the user the job acts on, if any
+	UserID int64 `json:"user_id,omitempty"`
+
+	// MessageID is the message the job acts on, if any
+	MessageID int `json:"message_id,omitempty"`
+
+	// Duration is the duration of the action (mute or ban) in seconds. Zero means forever
+	Duration uint `json:"duration,omitempty"`
+
+	// Reason is the reason recorded in the log
+	Reason string `json:"reason,omitempty"`
+
+	// LockdownID is the lockdown the job refers to, if any
+	LockdownID string `json:"lockdown_id,omitempty"`
+
+	// Attempts is the number of failed executions
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// claimJobsScript atomically returns the IDs of (at most ARGV[3]) jobs in the
+// queue KEYS[1] due before ARGV[1], moving them to ARGV[2]. This way, a job is
+// claimed by one worker only, and it's executed again if the worker doesn't
+// complete it before ARGV[2] (e.g. the bot crashed).
+var claimJobsScript = redis.NewScript(`
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call("ZADD", KEYS[1], ARGV[2], id)
+end
+return ids
+`)
+
+// ScheduleJob saves the given job, to be executed at the given time. If the job
+// has no ID, a new ID is assigned; otherwise, the previous job with the same ID
+// is replaced (e.g. for retries). It returns the job ID.
+//
+// Jobs are serialized as JSON inside the "jobs" HSET (the field name is the job
+// ID), and their execution times (unix time in milliseconds) are in the
+// "jobs:queue" ZSET.
+func (db *Database) ScheduleJob(job Job, at time.Time) (string, error) {
+	if job.ID == "" {
+		id, err := db.conn.Incr(context.TODO(), "jobs:id").Result()
+		if err != nil {
+			return "", fmt.Errorf("on \"INCR jobs:id\": %w", err)
+		}
+		job.ID = strconv.FormatInt(id, 10)
+	}
+
+	jsonb, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+
+	pipe := db.conn.TxPipeline()
+	pipe.HSet(context.TODO(), "jobs", job.ID, jsonb)
+	pipe.ZAdd(context.TODO(), "jobs:queue", &redis.Z{Score: float64(unixMilli(at)), Member: job.ID})
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return "", fmt.Errorf("on \"HSET jobs\": %w", err)
+	}
+	return job.ID, nil
+}
+
+// ClaimDueJobs returns at most limit jobs due before the given time. Returned
+// jobs are postponed by lease: the caller should call CompleteJob or ScheduleJob
+// (for retrying) before the lease expires, otherwise jobs are returned again.
+//
+// Jobs that cannot be decoded are removed, so they don't block the queue, and
+// their IDs are returned in corrupt.
+func (db *Database) ClaimDueJobs(now time.Time, lease time.Duration, limit int) (jobs []Job, corrupt []string, err error) {
+	ids, err := claimJobsScript.Run(context.TODO(), db.conn, []string{"jobs:queue"},
+		unixMilli(now), unixMilli(now.Add(lease)), limit).StringSlice()
+	if err != nil {
+		return nil, nil, fmt.Errorf("on claiming jobs: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	values, err := db.conn.HMGet(context.TODO(), "jobs", ids...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("on \"HMGET jobs\": %w", err)
+	}
+	jobs, stale, corrupt := decodeJobs(ids, values)
+	if drop := append(stale, corrupt...); len(drop) > 0 {
+		members := make([]interface{}, 0, len(drop))
+		for _, id := range drop {
+			members = append(members, id)
+		}
+		pipe := db.conn.TxPipeline()
+		pipe.HDel(context.TODO(), "jobs", drop...)
+		pipe.ZRem(context.TODO(), "jobs:queue", members...)
+		if _, err := pipe.Exec(context.TODO()); err != nil {
+			return nil, nil, fmt.Errorf("on \"HDEL jobs\": %w", err)
+		}
+	}
+	return jobs, corrupt, nil
+}
+
+// decodeJobs decodes the values returned by HMGET for the given job IDs. It
+// returns the decoded jobs, the IDs without a job (stale queue entries) and the
+// IDs of the jobs that cannot be decoded.
+func decodeJobs(ids []string, values []interface{}) (jobs []Job, stale []string, corrupt []string) {
+	jobs = make([]Job, 0, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(s), &job); err != nil {
+			corrupt = append(corrupt, ids[i])
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, stale, corrupt
+}
+
+// CompleteJob removes the job with the given ID.
+func (db *Database) CompleteJob(id string) error {
+	pipe := db.conn.TxPipeline()
+	pipe.HDel(context.TODO(), "jobs", id)
+	pipe.ZRem(context.TODO(), "jobs:queue", id)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return fmt.Errorf("on \"HDEL jobs\": %w", err)
+	}
+	return nil
+}
+
+// JobQueueStats returns the number of jobs in the queue, and how late the
+// oldest due job is at the given time (zero if no job is due).
+func (db *Database) JobQueueStats(now time.Time) (int64, time.Duration, error) {
+	pipe := db.conn.Pipeline()
+	depth := pipe.ZCard(context.TODO(), "jobs:queue")
+	oldest := pipe.ZRangeWithScores(context.TODO(), "jobs:queue", 0, 0)
+	if _, err := pipe.Exec(context.TODO()); err != nil && err != redis.Nil {
+		return 0, 0, fmt.Errorf("on \"ZCARD jobs:queue\": %w", err)
+	}
+
+	var lag time.Duration
+	if zs := oldest.Val(); len(zs) > 0 {
+		if late := unixMilli(now) - int64(zs[0].Score); late > 0 {
+			lag = time.Duration(late) * time.Millisecond
+		}
+	}
+	return depth.Val(), lag, nil
+}
+
+// unixMilli returns t as unix time in milliseconds.
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeJobs(t *testing.T) {
+	ids := []string{"1", "2", "3", "4"}
+	values := []interface{}{
+		`{"id":"1","kind":"delete","chat_id":-100,"message_id":10}`,
+		`{"id":"2","kind":`,
+		nil,
+		`{"id":"4","kind":"kick","chat_id":-100,"user_id":42,"reason":"CAPTCHA: timeout"}`,
+	}
+
+	jobs, stale, corrupt := decodeJobs(ids, values)
+
+	wantJobs := []Job{
+		{ID: "1", Kind: "delete", ChatID: -100, MessageID: 10},
+		{ID: "4", Kind: "kick", ChatID: -100, UserID: 42, Reason: "CAPTCHA: timeout"},
+	}
+	if !reflect.DeepEqual(jobs, wantJobs) {
+		t.Errorf("jobs = %+v, want %+v", jobs, wantJobs)
+	}
+	if want := []string{"3"}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("stale = %v, want %v", stale, want)
+	}
+	if want := []string{"2"}; !reflect.DeepEqual(corrupt, want) {
+		t.Errorf("corrupt = %v, want %v", corrupt, want)
+	}
+}
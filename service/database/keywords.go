@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// KeywordRule is a rule of the per-chat keyword blocklist.
+type KeywordRule struct {
+	// Pattern is the keyword, or the RE2 regular expression if Regexp is true
+	Pattern string `json:"pattern"`
+
+	// Regexp is true if Pattern is a regular expression
+	Regexp bool `json:"regexp"`
+}
+
+// String returns the rule in a human-readable form. Regular expressions are
+// enclosed in slashes.
+func (r KeywordRule) String() string {
+	if r.Regexp {
+		return "/" + r.Pattern + "/"
+	}
+	return r.Pattern
+}
+
+// GetKeywords returns the keyword blocklist for the given chat ID. If there are
+// no rules, it returns an empty list.
+func (db *Database) GetKeywords(chatID int64) ([]KeywordRule, error) {
+	// The blocklist is serialized as JSON inside the "keywords" HSET (the field
+	// name is the chat ID as string), like chat settings.
+	var rules []KeywordRule
+	jsonb, err := db.conn.HGet(context.TODO(), "keywords", strconv.FormatInt(chatID, 10)).Result()
+	if err == redis.Nil {
+		return rules, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("on \"HGET keywords\": %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(jsonb), &rules); err != nil {
+		return nil, fmt.Errorf("error decoding keywords from JSON: %w", err)
+	}
+	return rules, nil
+}
+
+// SetKeywords saves the keyword blocklist for the given chat ID, replacing the
+// previous one.
+func (db *Database) SetKeywords(chatID int64, rules []KeywordRule) error {
+	if len(rules) == 0 {
+		return db.conn.HDel(context.TODO(), "keywords", strconv.FormatInt(chatID, 10)).Err()
+	}
+	jsonb, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	return db.conn.HSet(context.TODO(), "keywords", strconv.FormatInt(chatID, 10), jsonb).Err()
+}
+
+// AddKeyword adds the given rule to the keyword blocklist of the given chat ID.
+// If the rule is already present, it does nothing.
+func (db *Database) AddKeyword(chatID int64, rule KeywordRule) error {
+	rules, err := db.GetKeywords(chatID)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r == rule {
+			return nil
+		}
+	}
+	return db.SetKeywords(chatID, append(rules, rule))
+}
+
+// RemoveKeyword removes the given rule from the keyword blocklist of the given
+// chat ID. If the rule is not present, it does nothing.
+func (db *Database) RemoveKeyword(chatID int64, rule KeywordRule) error {
+	rules, err := db.GetKeywords(chatID)
+	if err != nil {
+		return err
+	}
+	ret := make([]KeywordRule, 0, len(rules))
+	for _, r := range rules {
+		if r != rule {
+			ret = append(ret, r)
+		}
+	}
+	return db.SetKeywords(chatID, ret)
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// memberJoinedTTL is the time after which the join time of a member is
+// forgotten (i.e. the member is no longer considered recent).
+const memberJoinedTTL = 30 * 24 * time.Hour
+
+// memberJoinedKey returns the key of the join time of the given user in the
+// given chat.
+func memberJoinedKey(chatID int64, userID int64) string {
+	return fmt.Sprintf("joined:%d:%d", chatID, userID)
+}
+
+// SetMemberJoined records that the given user joined the given chat at the
+// given time. The record expires after 30 days.
+func (db *Database) SetMemberJoined(chatID int64, userID int64, at time.Time) error {
+	key := memberJoinedKey(chatID, userID)
+	if err := db.conn.Set(context.TODO(), key, at.Unix(), memberJoinedTTL).Err(); err != nil {
+		return fmt.Errorf("on \"SET %s\": %w", key, err)
+	}
+	return nil
+}
+
+// MemberJoinedAt returns when the given user joined the given chat. It returns
+// the zero time if the join is unknown or older than 30 days.
+func (db *Database) MemberJoinedAt(chatID int64, userID int64) (time.Time, error) {
+	key := memberJoinedKey(chatID, userID)
+	value, err := db.conn.Get(context.TODO(), key).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, fmt.Errorf("on \"GET %s\": %w", key, err)
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error decoding join time: %w", err)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// memberSeenKey returns the key that marks that the given user sent a message
+// in the given chat.
+func memberSeenKey(chatID int64, userID int64) string {
+	return fmt.Sprintf("seen:%d:%d", chatID, userID)
+}
+
+// SetMemberSeen records that the given user sent a message in the given chat.
+// It returns true if it is the first message of the user in the last 30 days.
+func (db *Database) SetMemberSeen(chatID int64, userID int64) (bool, error) {
+	key := memberSeenKey(chatID, userID)
+	first, err := db.conn.SetNX(context.TODO(), key, 1, memberJoinedTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("on \"SETNX %s\": %w", key, err)
+	}
+	return first, nil
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ChatUser identifies a user in a chat.
+type ChatUser struct {
+	ChatID int64
+	UserID int64
+}
+
+// chatUserMember returns the member (or field) name for the given chat and user
+// in sorted sets and hashes indexed by chat and user.
+func chatUserMember(chatID int64, userID int64) string {
+	return strconv.FormatInt(chatID, 10) + ":" + strconv.FormatInt(userID, 10)
+}
+
+// AddProbation puts the given user of the given chat in probation, until the
+// given time. If until is zero, the probation never expires by time.
+//
+// Users in probation are in the "probation" ZSET, with the expiry unix time as
+// score (zero if none), and their clean messages count is in the
+// "probation:messages" HSET.
+func (db *Database) AddProbation(chatID int64, userID int64, until time.Time) error {
+	var score float64
+	if !until.IsZero() {
+		score = float64(until.Unix())
+	}
+	member := chatUserMember(chatID, userID)
+
+	pipe := db.conn.TxPipeline()
+	pipe.ZAdd(context.TODO(), "probation", &redis.Z{Score: score, Member: member})
+	pipe.HDel(context.TODO(), "probation:messages", member)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return fmt.Errorf("on \"ZADD probation\": %w", err)
+	}
+	return nil
+}
+
+// IsInProbation returns true if the given user of the given chat is in
+// probation.
+func (db *Database) IsInProbation(chatID int64, userID int64) (bool, error) {
+	err := db.conn.ZScore(context.TODO(), "probation", chatUserMember(chatID, userID)).Err()
+	if err == redis.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("on \"ZSCORE probation\": %w", err)
+	}
+	return true, nil
+}
+
+// CountProbationMessage increments the clean messages count of the given user
+// in probation, and returns the new count.
+func (db *Database) CountProbationMessage(chatID int64, userID int64) (int64, error) {
+	count, err := db.conn.HIncrBy(context.TODO(), "probation:messages", chatUserMember(chatID, userID), 1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("on \"HINCRBY probation:messages\": %w", err)
+	}
+	return count, nil
+}
+
+// RemoveProbation removes the given user of the given chat from probation.
+func (db *Database) RemoveProbation(chatID int64, userID int64) error {
+	member := chatUserMember(chatID, userID)
+
+	pipe := db.conn.TxPipeline()
+	pipe.ZRem(context.TODO(), "probation", member)
+	pipe.HDel(context.TODO(), "probation:messages", member)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return fmt.Errorf("on \"ZREM probation\": %w", err)
+	}
+	return nil
+}
+
+// ProbationUntil returns the expiry time of the probation of the given user in
+// the given chat. found is false if the user is not in probation. If the
+// probation never expires by time, until is zero.
+func (db *Database) ProbationUntil(chatID int64, userID int64) (until time.Time, found bool, err error) {
+	score, err := db.conn.ZScore(context.TODO(), "probation", chatUserMember(chatID, userID)).Result()
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		return time.Time{}, false, fmt.Errorf("on \"ZSCORE probation\": %w", err)
+	}
+	if score > 0 {
+		until = time.Unix(int64(score), 0)
+	}
+	return until, true, nil
+}
+
+// ChatProbations returns the IDs of the users in probation in the given chat.
+func (db *Database) ChatProbations(chatID int64) ([]int64, error) {
+	var users []int64
+	var cursor uint64 = 0
+	var err error
+	var keys []string
+	for {
+		keys, cursor, err = db.conn.ZScan(context.TODO(), "probation", cursor, strconv.FormatInt(chatID, 10)+":*", -1).Result()
+		if errors.Is(err, redis.Nil) {
+			return users, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("on scanning users in \"probation\": %w", err)
+		}
+
+		// ZSCAN returns members and scores.
+		for i := 0; i < len(keys); i += 2 {
+			cu, ok := parseChatUserMember(keys[i])
+			if !ok || cu.ChatID != chatID {
+				continue
+			}
+			users = append(users, cu.UserID)
+		}
+
+		if cursor == 0 {
+			return users, nil
+		}
+	}
+}
+
+// parseChatUserMember parses a member name returned by chatUserMember.
+func parseChatUserMember(member string) (ChatUser, bool) {
+	parts := strings.SplitN(member, ":", 2)
+	if len(parts) != 2 {
+		return ChatUser{}, false
+	}
+	chatID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ChatUser{}, false
+	}
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ChatUser{}, false
+	}
+	return ChatUser{ChatID: chatID, UserID: userID}, true
+}
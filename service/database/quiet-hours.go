@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrQuietHoursNotFound = errors.New("quiet hours not found")
+)
+
+// quietHoursKey returns the key of the active quiet hours of the given chat.
+func quietHoursKey(chatID int64) string {
+	return fmt.Sprintf("quiethours:%d", chatID)
+}
+
+// StartQuietHours marks the quiet hours of the given chat as active, saving the
+// given default permissions of the chat (to be restored at the end). It returns
+// false if the quiet hours are already active.
+func (db *Database) StartQuietHours(chatID int64, permissions []byte) (bool, error) {
+	ok, err := db.conn.SetNX(context.TODO(), quietHoursKey(chatID), permissions, 0).Result()
+	if err != nil {
+		return false, fmt.Errorf("on \"SETNX %s\": %w", quietHoursKey(chatID), err)
+	}
+	return ok, nil
+}
+
+// IsInQuietHours returns true if the quiet hours of the given chat are active.
+func (db *Database) IsInQuietHours(chatID int64) (bool, error) {
+	n, err := db.conn.Exists(context.TODO(), quietHoursKey(chatID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("on \"EXISTS %s\": %w", quietHoursKey(chatID), err)
+	}
+	return n > 0, nil
+}
+
+// EndQuietHours marks the quiet hours of the given chat as not active, and
+// returns the saved default permissions. It returns ErrQuietHoursNotFound if
+// the quiet hours are not active.
+func (db *Database) EndQuietHours(chatID int64) ([]byte, error) {
+	pipe := db.conn.TxPipeline()
+	get := pipe.Get(context.TODO(), quietHoursKey(chatID))
+	pipe.Del(context.TODO(), quietHoursKey(chatID))
+	_, err := pipe.Exec(context.TODO())
+	if err == redis.Nil {
+		return nil, ErrQuietHoursNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("on \"GET %s\": %w", quietHoursKey(chatID), err)
+	}
+	return []byte(get.Val()), nil
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrLockdownNotFound = errors.New("lockdown not found")
+)
+
+// AddJoin records a join of the given user in the given chat, and returns the
+// IDs of all users that joined the chat in the last window (including the given
+// one).
+//
+// Joins are in the "raid:joins:CHAT ID" ZSET, with the join unix time in
+// milliseconds as score.
+func (db *Database) AddJoin(chatID int64, userID int64, window time.Duration) ([]int64, error) {
+	key := fmt.Sprintf("raid:joins:%d", chatID)
+	now := time.Now()
+	min := strconv.FormatInt(unixMilli(now.Add(-window)), 10)
+
+	pipe := db.conn.TxPipeline()
+	pipe.ZRemRangeByScore(context.TODO(), key, "-inf", "("+min)
+	pipe.ZAdd(context.TODO(), key, &redis.Z{
+		Score:  float64(unixMilli(now)),
+		Member: strconv.FormatInt(userID, 10),
+	})
+	members := pipe.ZRange(context.TODO(), key, 0, -1)
+	pipe.Expire(context.TODO(), key, window)
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return nil, fmt.Errorf("on counting %q: %w", key, err)
+	}
+
+	ret := make([]int64, 0, len(members.Val()))
+	for _, member := range members.Val() {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding user ID in %q: %w", key, err)
+		}
+		ret = append(ret, id)
+	}
+	return ret, nil
+}
+
+// Lockdown is the state of a chat in lockdown.
+type Lockdown struct {
+	// ID identifies the lockdown, so delayed actions of a lockdown are not
+	// applied to a later one
+	ID string
+
+	// Permissions are the default permissions of the chat before the
+	// lockdown, JSON encoded
+	Permissions []byte
+
+	// Until is when the lockdown ends, unless an admin ends it earlier
+	Until time.Time
+
+	// Muted are the users muted because they joined during the lockdown
+	Muted []int64
+}
+
+// lockdownKeys returns the keys of the lockdown of the given chat: the saved
+// permissions, the lockdown ID, the muted users and the end time.
+func lockdownKeys(chatID int64) []string {
+	key := fmt.Sprintf("lockdown:%d", chatID)
+	return []string{key, key + ":id", key + ":muted", key + ":until"}
+}
+
+// startLockdownScript marks the chat in lockdown if it isn't, saving the
+// permissions ARGV[1], the ID ARGV[2], the end time ARGV[3] and the muted users
+// ARGV[4:]. It returns 0 if the chat is already in lockdown.
+var startLockdownScript = redis.NewScript(`
+if redis.call("SETNX", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("SET", KEYS[2], ARGV[2])
+redis.call("SET", KEYS[4], ARGV[3])
+redis.call("DEL", KEYS[3])
+if #ARGV > 3 then
+	redis.call("SADD", KEYS[3], unpack(ARGV, 4))
+end
+return 1
+`)
+
+// endLockdownScript removes the lockdown if its ID is ARGV[1] (or if ARGV[1]
+// is empty), and returns the permissions, the ID, the end time and the muted
+// users. It returns nil if the chat is not in lockdown, or if the ID is
+// different.
+var endLockdownScript = redis.NewScript(`
+local permissions = redis.call("GET", KEYS[1])
+if not permissions then
+	return nil
+end
+local id = redis.call("GET", KEYS[2]) or ""
+if ARGV[1] ~= "" and ARGV[1] ~= id then
+	return nil
+end
+local ret = {permissions, id, redis.call("GET", KEYS[4]) or "0"}
+for _, member in ipairs(redis.call("SMEMBERS", KEYS[3])) do
+	table.insert(ret, member)
+end
+redis.call("DEL", KEYS[1], KEYS[2], KEYS[3], KEYS[4])
+return ret
+`)
+
+// StartLockdown marks the given chat in lockdown, saving the given lockdown
+// (to restore the default permissions at the end). It returns false if the
+// chat is already in lockdown.
+//
+// The default permissions are in the "lockdown:CHAT ID" key, the lockdown ID in
+// "lockdown:CHAT ID:id", the end unix time in "lockdown:CHAT ID:until" and the
+// muted users in the "lockdown:CHAT ID:muted" SET.
+func (db *Database) StartLockdown(chatID int64, lockdown Lockdown) (bool, error) {
+	args := []interface{}{lockdown.Permissions, lockdown.ID, lockdown.Until.Unix()}
+	for _, userID := range lockdown.Muted {
+		args = append(args, strconv.FormatInt(userID, 10))
+	}
+	ok, err := startLockdownScript.Run(context.TODO(), db.conn, lockdownKeys(chatID), args...).Bool()
+	if err != nil {
+		return false, fmt.Errorf("on starting lockdown: %w", err)
+	}
+	return ok, nil
+}
+
+// AddLockdownMuted records that the given user has been muted because of the
+// lockdown of the given chat.
+func (db *Database) AddLockdownMuted(chatID int64, userIDs ...int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	key := lockdownKeys(chatID)[2]
+	members := make([]interface{}, 0, len(userIDs))
+	for _, userID := range userIDs {
+		members = append(members, strconv.FormatInt(userID, 10))
+	}
+	if err := db.conn.SAdd(context.TODO(), key, members...).Err(); err != nil {
+		return fmt.Errorf("on \"SADD %s\": %w", key, err)
+	}
+	return nil
+}
+
+// IsInLockdown returns true if the given chat is in lockdown.
+func (db *Database) IsInLockdown(chatID int64) (bool, error) {
+	key := lockdownKeys(chatID)[0]
+	n, err := db.conn.Exists(context.TODO(), key).Result()
+	if err != nil {
+		return false, fmt.Errorf("on \"EXISTS %s\": %w", key, err)
+	}
+	return n > 0, nil
+}
+
+// LockdownUntil returns when the lockdown of the given chat ends. found is
+// false if the chat is not in lockdown.
+func (db *Database) LockdownUntil(chatID int64) (until time.Time, found bool, err error) {
+	key := lockdownKeys(chatID)[3]
+	unix, err := db.conn.Get(context.TODO(), key).Int64()
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		return time.Time{}, false, fmt.Errorf("on \"GET %s\": %w", key, err)
+	}
+	return time.Unix(unix, 0), true, nil
+}
+
+// EndLockdown removes the lockdown of the given chat, and returns it. If id is
+// not empty, the lockdown is removed only if it has the given ID. It returns
+// ErrLockdownNotFound if the chat is not in lockdown (or the ID is different),
+// so only one caller acts on the end of a lockdown.
+func (db *Database) EndLockdown(chatID int64, id string) (Lockdown, error) {
+	values, err := endLockdownScript.Run(context.TODO(), db.conn, lockdownKeys(chatID), id).StringSlice()
+	if err == redis.Nil {
+		return Lockdown{}, ErrLockdownNotFound
+	} else if err != nil {
+		return Lockdown{}, fmt.Errorf("on ending lockdown: %w", err)
+	}
+
+	lockdown := Lockdown{Permissions: []byte(values[0]), ID: values[1]}
+	if unix, err := strconv.ParseInt(values[2], 10, 64); err == nil && unix > 0 {
+		lockdown.Until = time.Unix(unix, 0)
+	}
+	for _, member := range values[3:] {
+		userID, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		lockdown.Muted = append(lockdown.Muted, userID)
+	}
+	return lockdown, nil
+}
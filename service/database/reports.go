@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrReportNotFound = errors.New("report not found")
+)
+
+// reportTTL is the time after which open reports are forgotten.
+const reportTTL = 7 * 24 * time.Hour
+
+// Report is an open report of a message, sent by a chat member to admins.
+type Report struct {
+	// ID is the unique ID of the report, assigned by AddReport
+	ID string `json:"id"`
+
+	// ChatID is the chat of the reported message
+	ChatID int64 `json:"chat_id"`
+
+	// MessageID is the reported message
+	MessageID int `json:"message_id"`
+
+	// UserID is the author of the reported message
+	UserID int64 `json:"user_id"`
+
+	// ReporterID is the user who sent the report
+	ReporterID int64 `json:"reporter_id"`
+
+	// Reason is the reason given by the reporter, if any
+	Reason string `json:"reason,omitempty"`
+
+	// Notifications are the messages sent to admins for this report
+	Notifications []ChatMessage `json:"notifications,omitempty"`
+}
+
+// reportKey returns the key of the report with the given ID.
+func reportKey(id string) string {
+	return "report:" + id
+}
+
+// AddReport saves the given report, assigning a new ID, and returns the ID.
+// Reports are serialized as JSON in "report:ID" keys, and they expire after one
+// week.
+func (db *Database) AddReport(report Report) (string, error) {
+	id, err := db.conn.Incr(context.TODO(), "reports:id").Result()
+	if err != nil {
+		return "", fmt.Errorf("on \"INCR reports:id\": %w", err)
+	}
+	report.ID = strconv.FormatInt(id, 10)
+	return report.ID, db.SetReport(report)
+}
+
+// SetReport replaces the given report (e.g. to add notifications).
+func (db *Database) SetReport(report Report) error {
+	jsonb, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if err := db.conn.Set(context.TODO(), reportKey(report.ID), jsonb, reportTTL).Err(); err != nil {
+		return fmt.Errorf("on \"SET %s\": %w", reportKey(report.ID), err)
+	}
+	return nil
+}
+
+// GetReport returns the open report with the given ID. If there is no report,
+// it returns ErrReportNotFound.
+func (db *Database) GetReport(id string) (Report, error) {
+	var report Report
+	jsonb, err := db.conn.Get(context.TODO(), reportKey(id)).Result()
+	if err == redis.Nil {
+		return report, ErrReportNotFound
+	} else if err != nil {
+		return report, fmt.Errorf("on \"GET %s\": %w", reportKey(id), err)
+	}
+	if err := json.Unmarshal([]byte(jsonb), &report); err != nil {
+		return report, fmt.Errorf("error decoding report from JSON: %w", err)
+	}
+	return report, nil
+}
+
+// CloseReport removes the open report with the given ID, and returns it. It
+// returns ErrReportNotFound if the report was already closed, so only one
+// caller acts on a report.
+func (db *Database) CloseReport(id string) (Report, error) {
+	var report Report
+	pipe := db.conn.TxPipeline()
+	get := pipe.Get(context.TODO(), reportKey(id))
+	pipe.Del(context.TODO(), reportKey(id))
+	_, err := pipe.Exec(context.TODO())
+	if err == redis.Nil {
+		return report, ErrReportNotFound
+	} else if err != nil {
+		return report, fmt.Errorf("on \"GET %s\": %w", reportKey(id), err)
+	}
+	if err := json.Unmarshal([]byte(get.Val()), &report); err != nil {
+		return report, fmt.Errorf("error decoding report from JSON: %w", err)
+	}
+	return report, nil
+}
+
+// CountReport records a report sent by the given user, and returns the number
+// of reports sent by that user in the last window.
+func (db *Database) CountReport(userID int64, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("reports:rate:%d", userID)
+	return db.countInWindow(key, strconv.FormatInt(time.Now().UnixNano(), 10), window)
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// strikesKey returns the key of the strikes counter for the given user in the
+// given chat.
+func strikesKey(chatID int64, userID int64) string {
+	return fmt.Sprintf("strikes:%d:%d", chatID, userID)
+}
+
+// AddStrike adds a strike to the given user in the given chat, and returns the
+// number of strikes of the user. Strikes decay: all strikes of the user are
+// forgotten after the given time without new strikes (zero means never).
+func (db *Database) AddStrike(chatID int64, userID int64, decay time.Duration) (int64, error) {
+	key := strikesKey(chatID, userID)
+
+	pipe := db.conn.TxPipeline()
+	count := pipe.Incr(context.TODO(), key)
+	if decay > 0 {
+		pipe.Expire(context.TODO(), key, decay)
+	} else {
+		pipe.Persist(context.TODO(), key)
+	}
+	if _, err := pipe.Exec(context.TODO()); err != nil {
+		return 0, fmt.Errorf("on \"INCR %s\": %w", key, err)
+	}
+	return count.Val(), nil
+}
+
+// RemoveStrike removes a strike from the given user in the given chat, and
+// returns the number of remaining strikes.
+func (db *Database) RemoveStrike(chatID int64, userID int64) (int64, error) {
+	key := strikesKey(chatID, userID)
+	count, err := db.conn.Decr(context.TODO(), key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("on \"DECR %s\": %w", key, err)
+	}
+	if count <= 0 {
+		if err := db.conn.Del(context.TODO(), key).Err(); err != nil {
+			return 0, fmt.Errorf("on \"DEL %s\": %w", key, err)
+		}
+		return 0, nil
+	}
+	return count, nil
+}
+
+// GetStrikes returns the number of strikes of the given user in the given chat.
+func (db *Database) GetStrikes(chatID int64, userID int64) (int64, error) {
+	key := strikesKey(chatID, userID)
+	count, err := db.conn.Get(context.TODO(), key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("on \"GET %s\": %w", key, err)
+	}
+	return count, nil
+}
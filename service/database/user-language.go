@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SetUserLanguage saves the language code of the given user, as sent by the
+// Telegram client.
+//
+// Languages are stored in the "user-languages" HSET, where the field name is
+// the user ID.
+func (db *Database) SetUserLanguage(userID int64, lang string) error {
+	if err := db.conn.HSet(context.TODO(), "user-languages", strconv.FormatInt(userID, 10), lang).Err(); err != nil {
+		return fmt.Errorf("on \"HSET user-languages\": %w", err)
+	}
+	return nil
+}
+
+// GetUserLanguage returns the language code of the given user. It returns an
+// empty string if the language is unknown.
+func (db *Database) GetUserLanguage(userID int64) (string, error) {
+	lang, err := db.conn.HGet(context.TODO(), "user-languages", strconv.FormatInt(userID, 10)).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("on \"HGET user-languages\": %w", err)
+	}
+	return lang, nil
+}